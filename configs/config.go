@@ -43,6 +43,99 @@ type Config struct {
 		Level compress.Level `toml:"level"`
 	} `toml:"compress"`
 
+	// 令牌设置
+	Token struct {
+		// 令牌签发者
+		Issuer string `toml:"issuer"`
+		// 访问令牌有效期，单位为秒
+		AccessExpireDuration int64 `toml:"access_expire_duration"`
+		// 刷新令牌有效期，单位为秒
+		RefreshExpireDuration int64 `toml:"refresh_expire_duration"`
+		// 每个用户最多同时持有的会话数量
+		MaxTokensPerUser int `toml:"max_tokens_per_user"`
+		// 用于签发令牌的密钥ID
+		SigningKeyID string `toml:"signing_key_id"`
+		// 密钥列表，除签发密钥外的密钥仅用于在轮换期内校验旧令牌
+		Keys []TokenKeyConfig `toml:"keys"`
+	} `toml:"token"`
+
+	// 密码哈希设置
+	Password struct {
+		// argon2id 内存开销，单位为 KiB
+		Memory uint32 `toml:"memory"`
+		// argon2id 迭代次数
+		Iterations uint32 `toml:"iterations"`
+		// argon2id 并行度
+		Parallelism uint8 `toml:"parallelism"`
+		// 盐的字节数
+		SaltLength uint32 `toml:"salt_length"`
+		// 哈希值的字节数
+		KeyLength uint32 `toml:"key_length"`
+	} `toml:"password"`
+
+	// 时间线设置
+	Timeline struct {
+		// 时间线生成策略 read, write
+		Strategy string `toml:"strategy"`
+	} `toml:"timeline"`
+
+	// 回收站设置
+	Trash struct {
+		// 已删除的博文与评论在回收站中的保留天数，超过后将被彻底清除
+		RetentionDays int `toml:"retention_days"`
+	} `toml:"trash"`
+
+	// 媒体文件存储设置
+	Storage struct {
+		// 存储后端 local, s3
+		Type string `toml:"type"`
+		// 本地文件系统存储设置
+		Local struct {
+			// 存储根目录
+			Root string `toml:"root"`
+			// 静态资源访问路径前缀
+			BaseURL string `toml:"base_url"`
+		} `toml:"local"`
+		// S3 兼容对象存储设置
+		S3 struct {
+			// 服务地址
+			Endpoint string `toml:"endpoint"`
+			// 区域
+			Region string `toml:"region"`
+			// 存储桶名称
+			Bucket string `toml:"bucket"`
+			// 访问密钥ID
+			AccessKeyID string `toml:"access_key_id"`
+			// 访问密钥
+			SecretAccessKey string `toml:"secret_access_key"`
+			// 是否使用路径形式访问存储桶
+			UsePathStyle bool `toml:"use_path_style"`
+			// 对象的公开访问地址前缀，留空时使用存储桶地址
+			PublicURL string `toml:"public_url"`
+		} `toml:"s3"`
+	} `toml:"storage"`
+
+	// 通知设置
+	Notifier struct {
+		// 通知发送方式 log, smtp
+		Type string `toml:"type"`
+		// log 方式下额外写入的文件路径，留空时仅输出到日志
+		File string `toml:"file"`
+		// SMTP 设置
+		SMTP struct {
+			// SMTP 服务器地址
+			Host string `toml:"host"`
+			// SMTP 服务器端口
+			Port int `toml:"port"`
+			// SMTP 用户名
+			Username string `toml:"username"`
+			// SMTP 密码
+			Password string `toml:"password"`
+			// 发件人地址
+			From string `toml:"from"`
+		} `toml:"smtp"`
+	} `toml:"notifier"`
+
 	// 环境设置
 	Env struct {
 		// 环境类型 development, production
@@ -50,6 +143,20 @@ type Config struct {
 	} `toml:"env"`
 }
 
+// TokenKeyConfig 令牌密钥配置
+type TokenKeyConfig struct {
+	// 密钥ID，签发令牌时写入 kid 头部
+	ID string `toml:"id"`
+	// 签名算法 HS256, RS256, EdDSA
+	Algorithm string `toml:"algorithm"`
+	// HS256 使用的密钥
+	Secret string `toml:"secret"`
+	// RS256, EdDSA 使用的 PEM 格式私钥文件路径，仅用于校验时可留空
+	PrivateKeyFile string `toml:"private_key_file"`
+	// RS256, EdDSA 使用的 PEM 格式公钥文件路径，提供私钥时可留空
+	PublicKeyFile string `toml:"public_key_file"`
+}
+
 // 配置文件对象工厂函数
 func NewConfig() (*Config, error) {
 	// 读取配置文件
@@ -0,0 +1,18 @@
+/*
+Package consts - NekoBlog backend server constants.
+This file is for user authority related constants.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package consts
+
+const (
+	// AUTHORITY_USER 普通用户
+	AUTHORITY_USER uint64 = 0
+
+	// AUTHORITY_MODERATOR 版主
+	AUTHORITY_MODERATOR uint64 = 1
+
+	// AUTHORITY_ADMIN 管理员
+	AUTHORITY_ADMIN uint64 = 2
+)
@@ -23,4 +23,10 @@ const (
 
 	// NETWORK_ERROR 网络错误
 	NETWORK_ERROR serializers.ResponseCode = 4
+
+	// PERMISSION_DENIED 权限不足
+	PERMISSION_DENIED serializers.ResponseCode = 5
+
+	// LOGIN_LOCKED 登录失败次数过多，暂时禁止登录
+	LOGIN_LOCKED serializers.ResponseCode = 6
 )
@@ -0,0 +1,46 @@
+/*
+Package consts - NekoBlog backend server constants.
+This file is for login protection related constants.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package consts
+
+import "time"
+
+const (
+	// LOGIN_FAILURE_REASON_PASSWORD 登录失败原因：密码错误
+	LOGIN_FAILURE_REASON_PASSWORD = "password error"
+
+	// LOGIN_FAILURE_REASON_USER_NOT_FOUND 登录失败原因：用户不存在
+	LOGIN_FAILURE_REASON_USER_NOT_FOUND = "user not found"
+
+	// LOGIN_FAILURE_REASON_LOCKED 登录失败原因：账户已被暂时锁定
+	LOGIN_FAILURE_REASON_LOCKED = "account locked"
+
+	// LOGIN_FAILURE_REASON_TOTP 登录失败原因：两步验证码错误
+	LOGIN_FAILURE_REASON_TOTP = "totp error"
+)
+
+const (
+	// LOGIN_FAILURE_WINDOW 统计登录失败次数的时间窗口
+	LOGIN_FAILURE_WINDOW = 15 * time.Minute
+
+	// LOGIN_DELAY_THRESHOLD 账户连续登录失败达到该次数后开始要求等待
+	LOGIN_DELAY_THRESHOLD = 3
+
+	// LOGIN_BASE_DELAY 首次要求等待的时长，此后每失败一次翻倍
+	LOGIN_BASE_DELAY = 2 * time.Second
+
+	// LOGIN_MAX_DELAY 渐进等待的最大时长
+	LOGIN_MAX_DELAY = 5 * time.Minute
+
+	// LOGIN_LOCK_THRESHOLD 账户连续登录失败达到该次数后锁定
+	LOGIN_LOCK_THRESHOLD = 10
+
+	// LOGIN_LOCK_DURATION 账户锁定时长，自最后一次失败起计算
+	LOGIN_LOCK_DURATION = 15 * time.Minute
+
+	// LOGIN_IP_FAILURE_LIMIT 同一IP在时间窗口内允许的最大登录失败次数
+	LOGIN_IP_FAILURE_LIMIT = 30
+)
@@ -0,0 +1,15 @@
+/*
+Package consts - NekoBlog backend server constants.
+This file is for pagination related constants.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package consts
+
+const (
+	// DEFAULT_PAGE_LIMIT 默认每页条目数
+	DEFAULT_PAGE_LIMIT = 20
+
+	// MAX_PAGE_LIMIT 每页最大条目数
+	MAX_PAGE_LIMIT = 100
+)
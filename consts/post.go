@@ -7,10 +7,25 @@ Copyright (c) [2024], Author(s):
 package consts
 
 const (
-	POST_IMAGE_MIN_WIDTH = 256
-	POST_IMAGE_MIN_HEIGHT = 128
-	POST_IMAGE_MAX_FILE_SIZE = 1024 * 1024 * 16 // 16 MB
+	POST_IMAGE_MIN_WIDTH        = 256
+	POST_IMAGE_MIN_HEIGHT       = 128
+	POST_IMAGE_MAX_FILE_SIZE    = 1024 * 1024 * 16 // 16 MB
 	POST_IMAGE_WIDTH_THRESHOLD  = 1920
 	POST_IMAGE_HEIGHT_THRESHOLD = 1080
-	POST_IMAGE_QUALITY = 75
+	POST_IMAGE_QUALITY          = 75
+	POST_MAX_IMAGE_COUNT        = 9
+)
+
+const (
+	// USER_POST_TAB_POSTS 用户主页博文标签页：用户发布的全部博文
+	USER_POST_TAB_POSTS = "posts"
+
+	// USER_POST_TAB_IMAGES 用户主页博文标签页：用户发布的带图片的博文
+	USER_POST_TAB_IMAGES = "images"
+
+	// USER_POST_TAB_LIKES 用户主页博文标签页：用户点赞的博文
+	USER_POST_TAB_LIKES = "likes"
+
+	// USER_POST_TAB_FAVORITES 用户主页博文标签页：用户收藏的博文，仅本人可见
+	USER_POST_TAB_FAVORITES = "favorites"
 )
@@ -1,10 +0,0 @@
-/*
-Package consts - NekoBlog backend server constants.
-This file is for salt related constants.
-Copyright (c) [2024], Author(s):
-- WhitePaper233<baizhiwp@gmail.com>
-*/
-package consts
-
-// SALT_LENGTH 密码盐的长度
-const SALT_LENGTH = 12
@@ -0,0 +1,18 @@
+/*
+Package consts - NekoBlog backend server constants.
+This file is for timeline related constants.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package consts
+
+const (
+	// TIMELINE_STRATEGY_READ 读扩散，读取时间线时实时查询关注用户的博文
+	TIMELINE_STRATEGY_READ = "read"
+
+	// TIMELINE_STRATEGY_WRITE 写扩散，发布博文时推送到关注者的时间线
+	TIMELINE_STRATEGY_WRITE = "write"
+
+	// TIMELINE_BACKFILL_LIMIT 写扩散模式下关注用户时补充到时间线的最近博文数
+	TIMELINE_BACKFILL_LIMIT = 100
+)
@@ -1,19 +1,37 @@
 /*
 Package consts - NekoBlog backend server constants.
-This file is for bearer token related constants.
+This file is for token related constants.
 Copyright (c) [2024], Author(s):
 - WhitePaper233<baizhiwp@gmail.com>
 */
 package consts
 
-// TOKEN_EXPIRE_DURATION 有效期
-const TOKEN_EXPIRE_DURATION = 7 * 24 * 60 * 60
+import "time"
 
-// TOKEN_SECRET 令牌密钥
-const TOKEN_SECRET = "NEKO_MICRO_BLOG_BACKEND_EXAMPLE_SECRET"
+// REFRESH_TOKEN_BYTES 刷新令牌的随机字节数
+const REFRESH_TOKEN_BYTES = 32
 
-// TOKEN_ISSUER 令牌签发者
-const TOKEN_ISSUER = "org.kirisakiii.neko"
+const (
+	// TOKEN_SUBJECT_BEARER 访问令牌的主题
+	TOKEN_SUBJECT_BEARER = "BearerToken"
 
-// MAX_TOKENS_PER_USER 最大令牌数量
-const MAX_TOKENS_PER_USER = 5
+	// TOKEN_SUBJECT_TOTP_CHALLENGE 两步验证挑战令牌的主题
+	TOKEN_SUBJECT_TOTP_CHALLENGE = "TOTPChallenge"
+)
+
+const (
+	// ONE_TIME_TOKEN_BYTES 一次性令牌的随机字节数
+	ONE_TIME_TOKEN_BYTES = 32
+
+	// ONE_TIME_TOKEN_PURPOSE_PASSWORD_RESET 一次性令牌用途：重置密码
+	ONE_TIME_TOKEN_PURPOSE_PASSWORD_RESET = "password_reset"
+
+	// ONE_TIME_TOKEN_PURPOSE_EMAIL_VERIFICATION 一次性令牌用途：验证邮箱
+	ONE_TIME_TOKEN_PURPOSE_EMAIL_VERIFICATION = "email_verification"
+
+	// PASSWORD_RESET_TOKEN_EXPIRE 重置密码令牌的有效期
+	PASSWORD_RESET_TOKEN_EXPIRE = 30 * time.Minute
+
+	// EMAIL_VERIFICATION_TOKEN_EXPIRE 验证邮箱令牌的有效期
+	EMAIL_VERIFICATION_TOKEN_EXPIRE = 24 * time.Hour
+)
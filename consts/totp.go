@@ -0,0 +1,32 @@
+/*
+Package consts - NekoBlog backend server constants.
+This file is for two-factor authentication related constants.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package consts
+
+import "time"
+
+const (
+	// TOTP_ISSUER 身份验证器中显示的签发者名称
+	TOTP_ISSUER = "NekoBlog"
+
+	// TOTP_SECRET_BYTES TOTP 密钥的字节数
+	TOTP_SECRET_BYTES = 20
+
+	// TOTP_PERIOD TOTP 时间步长，单位为秒
+	TOTP_PERIOD = 30
+
+	// TOTP_DIGITS TOTP 验证码位数
+	TOTP_DIGITS = 6
+
+	// TOTP_SKEW 允许的时间步偏差，用于容忍客户端时钟误差
+	TOTP_SKEW = 1
+
+	// TOTP_CHALLENGE_EXPIRE 两步验证挑战令牌的有效期
+	TOTP_CHALLENGE_EXPIRE = 5 * time.Minute
+
+	// RECOVERY_CODE_COUNT 每次生成的恢复码数量
+	RECOVERY_CODE_COUNT = 10
+)
@@ -0,0 +1,18 @@
+/*
+Package consts - NekoBlog backend server constants.
+This file is for content visibility related constants.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package consts
+
+const (
+	// VISIBILITY_PUBLIC 所有人可见
+	VISIBILITY_PUBLIC = "public"
+
+	// VISIBILITY_FOLLOWERS 仅关注者可见
+	VISIBILITY_FOLLOWERS = "followers"
+
+	// VISIBILITY_PRIVATE 仅自己可见
+	VISIBILITY_PRIVATE = "private"
+)
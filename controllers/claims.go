@@ -0,0 +1,30 @@
+/*
+Package controllers - NekoBlog backend server controllers.
+This file is for token claims helpers.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+)
+
+// getViewerUID 获取当前访问者的用户ID。
+//
+// 用于挂载了可选认证中间件的路由，匿名访问时返回 0。
+//
+// 参数：
+//   - ctx：Fiber 上下文
+//
+// 返回值：
+//   - uint64：访问者的用户ID。
+func getViewerUID(ctx *fiber.Ctx) uint64 {
+	claims, ok := ctx.Locals("claims").(*types.BearerTokenClaims)
+	if !ok || claims == nil {
+		return 0
+	}
+	return claims.UID
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/Kirisakiii/neko-micro-blog-backend/services"
 	"github.com/Kirisakiii/neko-micro-blog-backend/stores"
 	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/authorizers"
 	"github.com/Kirisakiii/neko-micro-blog-backend/utils/serializers"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -69,8 +70,14 @@ func (controller *CommentController) NewCreateCommentHandler(postStore *stores.P
 			)
 		}
 
+		// 评论默认公开
+		isPublic := true
+		if reqBody.IsPublic != nil {
+			isPublic = *reqBody.IsPublic
+		}
+
 		// 调用服务方法创建评论
-		err = controller.commentService.CreateComment(claims.UID, *reqBody.PostID, reqBody.Content, postStore, userStore)
+		err = controller.commentService.CreateComment(claims.UID, *reqBody.PostID, reqBody.ParentID, reqBody.Content, isPublic, postStore, userStore)
 		if err != nil {
 			return ctx.Status(200).JSON(
 				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
@@ -115,7 +122,12 @@ func (controller *CommentController) NewUpdateCommentHandler() fiber.Handler {
 		}
 
 		// 调用服务方法修改评论
-		err = controller.commentService.UpdateComment(*reqBody.CommentID, reqBody.Content)
+		err = controller.commentService.UpdateComment(claims.UID, *reqBody.CommentID, reqBody.Content)
+		if errors.Is(err, authorizers.ErrPermissionDenied) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PERMISSION_DENIED, err.Error()),
+			)
+		}
 		if err != nil {
 			return ctx.Status(200).JSON(
 				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
@@ -150,8 +162,106 @@ func (controller *CommentController) DeleteCommentHandler() fiber.Handler {
 			)
 		}
 
+		// 获取Token Claims
+		claims := c.Locals("claims").(*types.BearerTokenClaims)
+
 		// 执行删除操作
-		if err := controller.commentService.DeleteComment(*reqBody.CommentID); err != nil {
+		err := controller.commentService.DeleteComment(claims.UID, *reqBody.CommentID)
+		if errors.Is(err, authorizers.ErrPermissionDenied) {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PERMISSION_DENIED, err.Error()),
+			)
+		}
+		if err != nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		return c.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed"),
+		)
+	}
+}
+
+// NewTrashedCommentListHandler 获取回收站评论列表请求
+//
+// 返回值：
+//   - fiber.Handler：新的获取回收站评论列表函数
+func (controller *CommentController) NewTrashedCommentListHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// 获取Token Claims
+		claims := c.Locals("claims").(*types.BearerTokenClaims)
+
+		// 解析分页参数
+		cursor, limit, err := parsePaginationQuery(c)
+		if err != nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 获取回收站评论
+		comments, nextCursor, err := controller.commentService.GetTrashedCommentList(claims.UID, cursor, limit)
+		if err != nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		return c.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewTrashedCommentListResponse(comments, nextCursor)),
+		)
+	}
+}
+
+// NewRestoreCommentHandler 从回收站恢复评论请求
+//
+// 返回值：
+//   - fiber.Handler：新的恢复评论函数
+func (controller *CommentController) NewRestoreCommentHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// 解析请求体中的数据
+		reqBody := new(types.UserCommentRestoreBody)
+		if err := c.BodyParser(reqBody); err != nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 检查评论ID是否为空
+		if reqBody.CommentID == nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "comment id is required"),
+			)
+		}
+
+		// 获取Token Claims
+		claims := c.Locals("claims").(*types.BearerTokenClaims)
+
+		// 执行恢复操作
+		err := controller.commentService.RestoreComment(claims.UID, *reqBody.CommentID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "comment is not in trash"),
+			)
+		}
+		if errors.Is(err, services.ErrCommentPostDeleted) {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+		if errors.Is(err, services.ErrCommentParentDeleted) {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+		if errors.Is(err, authorizers.ErrPermissionDenied) {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PERMISSION_DENIED, err.Error()),
+			)
+		}
+		if err != nil {
 			return c.Status(200).JSON(
 				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
 			)
@@ -170,14 +280,81 @@ func (controller *CommentController) DeleteCommentHandler() fiber.Handler {
 //   - 失败返回nil
 func (controller *CommentController) NewCommentListHandler() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		comments, err := controller.commentService.GetCommentList()
+		// 获取博文ID
+		postIDString := c.Query("post-id")
+		if postIDString == "" {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "post id is required"),
+			)
+		}
+		postID, err := strconv.ParseUint(postIDString, 10, 64)
+		if err != nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 解析分页参数
+		cursor, limit, err := parsePaginationQuery(c)
+		if err != nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		comments, nextCursor, err := controller.commentService.GetCommentList(postID, getViewerUID(c), cursor, limit)
 		if err != nil {
 			return c.Status(200).JSON(
 				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
 			)
 		}
 		return c.Status(200).JSON(
-			serializers.NewCommentListResponse(comments),
+			serializers.NewCommentListResponse(comments, nextCursor),
+		)
+	}
+}
+
+// NewReplyListHandler 获取评论回复列表请求
+//
+// 返回值：
+//   - fiber.Handler：新的获取评论回复列表的函数
+func (controller *CommentController) NewReplyListHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// 获取评论ID
+		commentIDString := c.Query("comment-id")
+		if commentIDString == "" {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "comment id is required"),
+			)
+		}
+		commentID, err := strconv.ParseUint(commentIDString, 10, 64)
+		if err != nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 解析分页参数
+		cursor, limit, err := parsePaginationQuery(c)
+		if err != nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		replies, nextCursor, err := controller.commentService.GetReplyList(commentID, getViewerUID(c), cursor, limit)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "comment does not exist"),
+			)
+		}
+		if err != nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+		return c.Status(200).JSON(
+			serializers.NewCommentListResponse(replies, nextCursor),
 		)
 	}
 }
@@ -202,7 +379,7 @@ func (controller *CommentController) NewCommentDetailHandler() fiber.Handler {
 			)
 		}
 
-		comment, err := controller.commentService.GetCommentInfo(commentID)
+		comment, err := controller.commentService.GetCommentInfo(commentID, getViewerUID(ctx))
 		// 若comment不存在则返回错误
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ctx.Status(200).JSON(
@@ -218,7 +395,77 @@ func (controller *CommentController) NewCommentDetailHandler() fiber.Handler {
 
 		// 返回结果
 		return ctx.Status(200).JSON(
-			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewCommentDetailResponse(comment)),
+			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewCommentDetailResponse(comment, getViewerUID(ctx))),
+		)
+	}
+}
+
+// NewLikeCommentHandler 处理点赞评论的请求
+//
+// 返回：
+//   - fiber.Handler：新的点赞评论函数
+func (controller *CommentController) NewLikeCommentHandler() fiber.Handler {
+	return newCommentReactionHandler(controller.commentService.LikeComment)
+}
+
+// NewDislikeCommentHandler 处理点踩评论的请求
+//
+// 返回：
+//   - fiber.Handler：新的点踩评论函数
+func (controller *CommentController) NewDislikeCommentHandler() fiber.Handler {
+	return newCommentReactionHandler(controller.commentService.DislikeComment)
+}
+
+// NewClearCommentReactionHandler 处理清除评论点赞或点踩的请求
+//
+// 返回：
+//   - fiber.Handler：新的清除评论点赞或点踩函数
+func (controller *CommentController) NewClearCommentReactionHandler() fiber.Handler {
+	return newCommentReactionHandler(controller.commentService.ClearCommentReaction)
+}
+
+// newCommentReactionHandler 返回对评论进行点赞、点踩等操作的处理函数
+//
+// 参数：
+//   - react：具体的操作
+//
+// 返回：
+//   - fiber.Handler：新的处理函数
+func newCommentReactionHandler(react func(uid uint64, commentID uint64) error) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 解析请求体
+		reqBody := new(types.UserCommentReactionBody)
+		if err := ctx.BodyParser(reqBody); err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 检查评论ID是否为空
+		if reqBody.CommentID == nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "comment id is required"),
+			)
+		}
+
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 执行操作
+		err := react(claims.UID, *reqBody.CommentID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "comment does not exist"),
+			)
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed"),
 		)
 	}
 }
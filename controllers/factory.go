@@ -9,16 +9,18 @@ package controllers
 
 import (
 	"github.com/Kirisakiii/neko-micro-blog-backend/services"
+	"github.com/Kirisakiii/neko-micro-blog-backend/storages"
 )
 
 // Factory 控制器工厂
 type Factory struct {
 	serviceFactory *services.Factory
+	mediaStorage   storages.MediaStorage
 }
 
 // NewFactory 创建控制器工厂
-func NewFactory(serviceFactory *services.Factory) *Factory {
-	return &Factory{serviceFactory: serviceFactory}
+func NewFactory(serviceFactory *services.Factory, mediaStorage storages.MediaStorage) *Factory {
+	return &Factory{serviceFactory: serviceFactory, mediaStorage: mediaStorage}
 }
 
 
@@ -0,0 +1,51 @@
+/*
+Package controllers - NekoBlog backend server controllers.
+This file is for pagination query parsing helpers.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/parsers"
+)
+
+// parsePaginationQuery 解析请求中的分页参数 cursor 与 limit。
+//
+// 参数：
+//   - ctx：Fiber 上下文
+//
+// 返回值：
+//   - *types.Cursor：分页游标，未提供时为 nil。
+//   - int：每页条目数。
+//   - error：如果参数不合法，则返回相应的错误信息，否则返回nil。
+func parsePaginationQuery(ctx *fiber.Ctx) (*types.Cursor, int, error) {
+	var (
+		cursor *types.Cursor
+		limit  = consts.DEFAULT_PAGE_LIMIT
+		err    error
+	)
+
+	if cursorString := ctx.Query("cursor"); cursorString != "" {
+		cursor, err = parsers.ParseCursor(cursorString)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if limitString := ctx.Query("limit"); limitString != "" {
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit <= 0 || limit > consts.MAX_PAGE_LIMIT {
+			return nil, 0, errors.New("limit must be between 1 and " + strconv.Itoa(consts.MAX_PAGE_LIMIT))
+		}
+	}
+
+	return cursor, limit, nil
+}
@@ -11,6 +11,7 @@ package controllers
 import (
 	"errors"
 	"fmt"
+	"mime/multipart"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -18,13 +19,17 @@ import (
 
 	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
 	"github.com/Kirisakiii/neko-micro-blog-backend/services"
+	"github.com/Kirisakiii/neko-micro-blog-backend/storages"
 	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/authorizers"
 	"github.com/Kirisakiii/neko-micro-blog-backend/utils/serializers"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/validers"
 )
 
 // PostController 博文控制器结构体
 type PostController struct {
-	postService *services.PostService
+	postService  *services.PostService
+	mediaStorage storages.MediaStorage
 }
 
 // NewPostController 博文控制器工厂函数。
@@ -33,7 +38,8 @@ type PostController struct {
 // - *PostController 博文控制器指针
 func (factory *Factory) NewPostController() *PostController {
 	return &PostController{
-		postService: factory.serviceFactory.NewPostService(),
+		postService:  factory.serviceFactory.NewPostService(),
+		mediaStorage: factory.mediaStorage,
 	}
 }
 
@@ -43,14 +49,100 @@ func (factory *Factory) NewPostController() *PostController {
 // - fiber.Handle：新的博文列表函数
 func (controller *PostController) NewPostListHandler() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		posts, err := controller.postService.GetPostList()
+		// 解析分页参数
+		cursor, limit, err := parsePaginationQuery(c)
+		if err != nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		posts, nextCursor, err := controller.postService.GetPostList(getViewerUID(c), cursor, limit)
+		if err != nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+		return c.Status(200).JSON(
+			serializers.NewPostListResponse(posts, nextCursor),
+		)
+	}
+}
+
+// NewUserPostListHandler 用户主页博文列表函数
+//
+// 返回值：
+// - fiber.Handle：新的用户主页博文列表函数
+func (controller *PostController) NewUserPostListHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// 获取用户ID
+		uid, err := strconv.ParseUint(c.Params("uid"), 10, 64)
+		if err != nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "uid must be a number"),
+			)
+		}
+
+		// 解析分页参数
+		cursor, limit, err := parsePaginationQuery(c)
+		if err != nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 获取博文列表
+		tab := c.Query("tab", consts.USER_POST_TAB_POSTS)
+		posts, nextCursor, err := controller.postService.GetUserPostList(uid, tab, getViewerUID(c), cursor, limit)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "user does not exist"),
+			)
+		case errors.Is(err, services.ErrUserPostTabInvalid):
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		case errors.Is(err, services.ErrFavoritesPrivate):
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PERMISSION_DENIED, err.Error()),
+			)
+		case err != nil:
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+		return c.Status(200).JSON(
+			serializers.NewPostListResponse(posts, nextCursor),
+		)
+	}
+}
+
+// NewTimelineHandler 首页时间线函数
+//
+// 返回值：
+// - fiber.Handle：新的首页时间线函数
+func (controller *PostController) NewTimelineHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// 获取Token Claims
+		claims := c.Locals("claims").(*types.BearerTokenClaims)
+
+		// 解析分页参数
+		cursor, limit, err := parsePaginationQuery(c)
+		if err != nil {
+			return c.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		posts, nextCursor, err := controller.postService.GetTimeline(claims.UID, cursor, limit)
 		if err != nil {
 			return c.Status(200).JSON(
 				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
 			)
 		}
 		return c.Status(200).JSON(
-			serializers.NewPostListResponse(posts),
+			serializers.NewPostListResponse(posts, nextCursor),
 		)
 	}
 }
@@ -78,7 +170,7 @@ func (controller *PostController) NewPostDetailHandler() fiber.Handler {
 		}
 
 		// 获取帖子的详细信息
-		post, err := controller.postService.GetPostInfo(postID)
+		post, err := controller.postService.GetPostInfo(postID, getViewerUID(ctx))
 		// 若post不存在则返回错误
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ctx.Status(200).JSON(
@@ -93,9 +185,17 @@ func (controller *PostController) NewPostDetailHandler() fiber.Handler {
 			)
 		}
 
+		// 获取原博文信息
+		parent, parentDeleted, err := controller.postService.GetParentPostInfo(post, getViewerUID(ctx))
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
 		// 返回结果
 		return ctx.Status(200).JSON(
-			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewPostDetailResponse(post)),
+			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewPostDetailResponse(post, parent, parentDeleted, getViewerUID(ctx), controller.mediaStorage)),
 		)
 	}
 }
@@ -124,6 +224,14 @@ func (controller *PostController) NewCreatePostHandler() fiber.Handler {
 				serializers.NewResponse(consts.PARAMETER_ERROR, "post title or post content"),
 			)
 		}
+		if reqBody.Visibility == "" {
+			reqBody.Visibility = consts.VISIBILITY_PUBLIC
+		}
+		if !validers.IsValidVisibility(reqBody.Visibility) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "invalid visibility"),
+			)
+		}
 
 		// 处理图片上传
 		form, err := ctx.MultipartForm()
@@ -135,7 +243,7 @@ func (controller *PostController) NewCreatePostHandler() fiber.Handler {
 		files := form.File["images"]
 
 		// 检查表单中文件的数量
-		if len(files) > 9 {
+		if len(files) > consts.POST_MAX_IMAGE_COUNT {
 			return ctx.Status(200).JSON(
 				serializers.NewResponse(consts.PARAMETER_ERROR, "The number of images cannot exceed 9"),
 			)
@@ -160,6 +268,194 @@ func (controller *PostController) NewCreatePostHandler() fiber.Handler {
 	}
 }
 
+// NewEditPostHandler 返回编辑博文的处理函数。
+//
+// 返回值：
+// - fiber.Handler：新的编辑博文函数
+func (controller *PostController) NewEditPostHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 提取令牌声明
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 获取PostID
+		postID, err := strconv.ParseUint(ctx.Params("post"), 10, 64)
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "post id must be a number"))
+		}
+
+		// 解析用户请求
+		reqBody := types.PostEditBody{}
+		err = ctx.BodyParser(&reqBody)
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()))
+		}
+
+		// 验证参数
+		if (reqBody.Title != nil && *reqBody.Title == "") || (reqBody.Content != nil && *reqBody.Content == "") {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "post title or post content cannot be empty"))
+		}
+		if reqBody.Visibility != nil && !validers.IsValidVisibility(*reqBody.Visibility) {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "invalid visibility"))
+		}
+
+		// 获取新上传的图片，非 multipart 请求时没有新图片
+		var files []*multipart.FileHeader
+		if form, err := ctx.MultipartForm(); err == nil {
+			files = form.File["images"]
+		}
+		if reqBody.Title == nil && reqBody.Content == nil && reqBody.Visibility == nil &&
+			len(reqBody.RemoveImages) == 0 && len(files) == 0 {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "nothing to edit"))
+		}
+
+		// 编辑博文
+		_, err = controller.postService.EditPost(claims.UID, postID, reqBody, files)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "post does not exist"))
+		}
+		if errors.Is(err, authorizers.ErrPermissionDenied) {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PERMISSION_DENIED, err.Error()))
+		}
+		if errors.Is(err, services.ErrTooManyPostImages) {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()))
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.SERVER_ERROR, err.Error()))
+		}
+
+		return ctx.Status(200).JSON(serializers.NewResponse(consts.SUCCESS, "succeed"))
+	}
+}
+
+// NewPostRevisionListHandler 返回获取博文修订记录的处理函数。
+//
+// 返回值：
+// - fiber.Handler：新的获取博文修订记录函数
+func (controller *PostController) NewPostRevisionListHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 获取PostID
+		postID, err := strconv.ParseUint(ctx.Params("post"), 10, 64)
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "post id must be a number"))
+		}
+
+		// 解析分页参数
+		cursor, limit, err := parsePaginationQuery(ctx)
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()))
+		}
+
+		// 提取令牌声明
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 获取修订记录
+		revisions, nextCursor, err := controller.postService.GetPostRevisions(claims.UID, postID, cursor, limit)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "post does not exist"))
+		}
+		if errors.Is(err, authorizers.ErrPermissionDenied) {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PERMISSION_DENIED, err.Error()))
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.SERVER_ERROR, err.Error()))
+		}
+
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewPostRevisionListResponse(revisions, nextCursor, controller.mediaStorage)),
+		)
+	}
+}
+
+// NewForwardPostHandler 返回转发博文的处理函数。
+//
+// 返回值：
+// - fiber.Handler：新的转发博文函数
+func (controller *PostController) NewForwardPostHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 提取令牌声明
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 解析用户请求
+		reqBody := types.PostForwardBody{}
+		err := ctx.BodyParser(&reqBody)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 验证参数
+		if reqBody.ParentPostID == nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "parent post id is required"),
+			)
+		}
+
+		// 转发博文
+		postInfo, err := controller.postService.ForwardPost(claims.UID, ctx.IP(), reqBody)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "parent post does not exist or is not public"),
+			)
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回成功响应
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(
+				consts.SUCCESS,
+				"post forwarded successfully",
+				serializers.NewCreatePostResponse(postInfo),
+			),
+		)
+	}
+}
+
+// NewUpdatePostVisibilityHandler 返回修改博文可见性的处理函数。
+//
+// 返回值：
+// - fiber.Handler：新的修改博文可见性函数
+func (controller *PostController) NewUpdatePostVisibilityHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 提取令牌声明
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 获取PostID
+		postID, err := strconv.ParseUint(ctx.Params("post"), 10, 64)
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "post id must be a number"))
+		}
+
+		// 解析用户请求
+		reqBody := types.PostUpdateVisibilityBody{}
+		err = ctx.BodyParser(&reqBody)
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()))
+		}
+		if !validers.IsValidVisibility(reqBody.Visibility) {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "invalid visibility"))
+		}
+
+		// 修改可见性
+		err = controller.postService.UpdatePostVisibility(claims.UID, postID, reqBody.Visibility)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "post does not exist"))
+		}
+		if errors.Is(err, authorizers.ErrPermissionDenied) {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PERMISSION_DENIED, err.Error()))
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.SERVER_ERROR, err.Error()))
+		}
+
+		return ctx.Status(200).JSON(serializers.NewResponse(consts.SUCCESS, "succeed"))
+	}
+}
+
 // NewDeletePostHandler 返回一个用于处理删除博文请求的 Fiber 处理函数
 //
 // 参数：
@@ -184,11 +480,142 @@ func (controller *PostController) NewDeletePostHandler() fiber.Handler {
 			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "post id must be a number"))
 		}
 
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
 		// 执行删除操作
-		if err := controller.postService.DeletePost(postIDUint); err != nil {
+		err = controller.postService.DeletePost(claims.UID, postIDUint)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "post does not exist"))
+		}
+		if errors.Is(err, authorizers.ErrPermissionDenied) {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PERMISSION_DENIED, err.Error()))
+		}
+		if err != nil {
 			return ctx.Status(200).JSON(serializers.NewResponse(consts.SERVER_ERROR, err.Error()))
 		}
 
 		return ctx.JSON(serializers.NewResponse(consts.SUCCESS, "succeed"))
 	}
 }
+
+// NewTrashedPostListHandler 返回获取回收站博文列表的处理函数。
+//
+// 返回值：
+// - fiber.Handler：新的获取回收站博文列表函数
+func (controller *PostController) NewTrashedPostListHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 解析分页参数
+		cursor, limit, err := parsePaginationQuery(ctx)
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()))
+		}
+
+		// 获取回收站博文
+		posts, nextCursor, err := controller.postService.GetTrashedPostList(claims.UID, cursor, limit)
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.SERVER_ERROR, err.Error()))
+		}
+
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewTrashedPostListResponse(posts, nextCursor, controller.mediaStorage)),
+		)
+	}
+}
+
+// NewRestorePostHandler 返回从回收站恢复博文的处理函数。
+//
+// 返回值：
+// - fiber.Handler：新的恢复博文函数
+func (controller *PostController) NewRestorePostHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 获取PostID
+		postID, err := strconv.ParseUint(ctx.Params("post"), 10, 64)
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "post id must be a number"))
+		}
+
+		// 恢复博文
+		err = controller.postService.RestorePost(claims.UID, postID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "post is not in trash"))
+		}
+		if errors.Is(err, authorizers.ErrPermissionDenied) {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PERMISSION_DENIED, err.Error()))
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.SERVER_ERROR, err.Error()))
+		}
+
+		return ctx.Status(200).JSON(serializers.NewResponse(consts.SUCCESS, "succeed"))
+	}
+}
+
+// NewLikePostHandler 返回点赞博文的处理函数。
+//
+// 返回值：
+// - fiber.Handler：新的点赞博文函数
+func (controller *PostController) NewLikePostHandler() fiber.Handler {
+	return newPostReactionHandler(controller.postService.LikePost)
+}
+
+// NewUnlikePostHandler 返回取消点赞博文的处理函数。
+//
+// 返回值：
+// - fiber.Handler：新的取消点赞博文函数
+func (controller *PostController) NewUnlikePostHandler() fiber.Handler {
+	return newPostReactionHandler(controller.postService.UnlikePost)
+}
+
+// NewFavoritePostHandler 返回收藏博文的处理函数。
+//
+// 返回值：
+// - fiber.Handler：新的收藏博文函数
+func (controller *PostController) NewFavoritePostHandler() fiber.Handler {
+	return newPostReactionHandler(controller.postService.FavoritePost)
+}
+
+// NewUnfavoritePostHandler 返回取消收藏博文的处理函数。
+//
+// 返回值：
+// - fiber.Handler：新的取消收藏博文函数
+func (controller *PostController) NewUnfavoritePostHandler() fiber.Handler {
+	return newPostReactionHandler(controller.postService.UnfavoritePost)
+}
+
+// newPostReactionHandler 返回对博文进行点赞、收藏等操作的处理函数。
+//
+// 参数：
+// - react func(uid uint64, postID uint64) error：具体的操作
+//
+// 返回值：
+// - fiber.Handler：新的处理函数
+func newPostReactionHandler(react func(uid uint64, postID uint64) error) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 获取PostID
+		postID, err := strconv.ParseUint(ctx.Params("post"), 10, 64)
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "post id must be a number"))
+		}
+
+		// 执行操作
+		err = react(claims.UID, postID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.PARAMETER_ERROR, "post does not exist"))
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(serializers.NewResponse(consts.SERVER_ERROR, err.Error()))
+		}
+
+		return ctx.Status(200).JSON(serializers.NewResponse(consts.SUCCESS, "succeed"))
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Package controllers - NekoBlog backend server controllers.
+This file is for relation controller, which is used to create handle user relationship related requests.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
+	"github.com/Kirisakiii/neko-micro-blog-backend/services"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/serializers"
+)
+
+// RelationController 用户关系控制器
+type RelationController struct {
+	relationService *services.RelationService
+}
+
+// NewRelationController 返回一个新的 RelationController 实例。
+//
+// 返回值：
+//   - *RelationController：新的 RelationController 实例。
+func (factory *Factory) NewRelationController() *RelationController {
+	return &RelationController{
+		relationService: factory.serviceFactory.NewRelationService(),
+	}
+}
+
+// NewFollowHandler 返回关注用户的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的关注用户的处理函数。
+func (controller *RelationController) NewFollowHandler() fiber.Handler {
+	return newRelationActionHandler(controller.relationService.FollowUser)
+}
+
+// NewUnfollowHandler 返回取消关注用户的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的取消关注用户的处理函数。
+func (controller *RelationController) NewUnfollowHandler() fiber.Handler {
+	return newRelationActionHandler(controller.relationService.UnfollowUser)
+}
+
+// NewBlockHandler 返回屏蔽用户的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的屏蔽用户的处理函数。
+func (controller *RelationController) NewBlockHandler() fiber.Handler {
+	return newRelationActionHandler(controller.relationService.BlockUser)
+}
+
+// NewUnblockHandler 返回解除屏蔽用户的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的解除屏蔽用户的处理函数。
+func (controller *RelationController) NewUnblockHandler() fiber.Handler {
+	return newRelationActionHandler(controller.relationService.UnblockUser)
+}
+
+// NewFollowerListHandler 返回获取粉丝列表的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的获取粉丝列表的处理函数。
+func (controller *RelationController) NewFollowerListHandler() fiber.Handler {
+	return newRelationListHandler(controller.relationService.GetFollowers)
+}
+
+// NewFollowingListHandler 返回获取关注列表的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的获取关注列表的处理函数。
+func (controller *RelationController) NewFollowingListHandler() fiber.Handler {
+	return newRelationListHandler(controller.relationService.GetFollowing)
+}
+
+// newRelationActionHandler 返回对其他用户进行关注、屏蔽等操作的处理函数。
+//
+// 参数：
+//   - act：具体的操作
+//
+// 返回值：
+//   - fiber.Handler：新的处理函数。
+func newRelationActionHandler(act func(uid uint64, targetUID uint64) error) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 获取目标用户ID
+		targetUID, err := strconv.ParseUint(ctx.Params("uid"), 10, 64)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "uid must be a number"),
+			)
+		}
+
+		// 执行操作
+		err = act(claims.UID, targetUID)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "user does not exist"),
+			)
+		case errors.Is(err, services.ErrFollowSelf), errors.Is(err, services.ErrBlockSelf):
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		case errors.Is(err, services.ErrUserBlocked):
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PERMISSION_DENIED, err.Error()),
+			)
+		case err != nil:
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed"),
+		)
+	}
+}
+
+// newRelationListHandler 返回分页获取粉丝、关注等用户列表的处理函数。
+//
+// 参数：
+//   - list：获取列表的函数
+//
+// 返回值：
+//   - fiber.Handler：新的处理函数。
+func newRelationListHandler(list func(uid uint64, cursor *types.Cursor, limit int) ([]uint64, *types.Cursor, error)) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 获取用户ID
+		uidString := ctx.Query("uid")
+		if uidString == "" {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "parameter uid is required"),
+			)
+		}
+		uid, err := strconv.ParseUint(uidString, 10, 64)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "uid must be a number"),
+			)
+		}
+
+		// 解析分页参数
+		cursor, limit, err := parsePaginationQuery(ctx)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 获取用户列表
+		uids, nextCursor, err := list(uid, cursor, limit)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "user does not exist"),
+			)
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewUserListResponse(uids, nextCursor)),
+		)
+	}
+}
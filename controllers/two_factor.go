@@ -0,0 +1,214 @@
+/*
+Package controllers - NekoBlog backend server controllers.
+This file is for two-factor authentication handlers.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
+	"github.com/Kirisakiii/neko-micro-blog-backend/services"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/serializers"
+)
+
+// NewTOTPLoginHandler 返回两步验证登录的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的两步验证登录的处理函数。
+func (controller *UserController) NewTOTPLoginHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 解析请求体
+		reqBody := new(types.UserTOTPLoginBody)
+		err := ctx.BodyParser(reqBody)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 校验参数
+		if reqBody.ChallengeToken == "" || reqBody.Code == "" {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "challenge token or code is required"),
+			)
+		}
+
+		// 解析 UA
+		browserInfo, os := parseLoginClient(ctx)
+
+		// 登陆
+		tokenPair, err := controller.userService.LoginUserWithTOTP(reqBody.ChallengeToken, reqBody.Code, ctx.IP(), browserInfo, os)
+		var lockedErr *services.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			return newLoginLockedResponse(ctx, lockedErr)
+		}
+		if errors.Is(err, services.ErrChallengeTokenInvalid) || errors.Is(err, services.ErrTOTPCodeInvalid) ||
+			errors.Is(err, services.ErrTOTPNotEnabled) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.AUTH_ERROR, err.Error()),
+			)
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewUserToken(tokenPair)),
+		)
+	}
+}
+
+// NewEnrollTOTPHandler 返回注册两步验证的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的注册两步验证的处理函数。
+func (controller *UserController) NewEnrollTOTPHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 解析请求体
+		reqBody := new(types.UserTOTPEnrollBody)
+		err := ctx.BodyParser(reqBody)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 校验参数
+		if reqBody.Password == "" {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "password is required"),
+			)
+		}
+
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 注册两步验证
+		enrollment, err := controller.userService.EnrollTOTP(claims.UID, reqBody.Password)
+		if errors.Is(err, services.ErrPasswordIncorrect) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.AUTH_ERROR, err.Error()),
+			)
+		}
+		if errors.Is(err, services.ErrTOTPAlreadyEnabled) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewTOTPEnrollmentData(enrollment)),
+		)
+	}
+}
+
+// NewActivateTOTPHandler 返回启用两步验证的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的启用两步验证的处理函数。
+func (controller *UserController) NewActivateTOTPHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 解析请求体
+		reqBody := new(types.UserTOTPActivateBody)
+		err := ctx.BodyParser(reqBody)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 校验参数
+		if reqBody.Code == "" {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "code is required"),
+			)
+		}
+
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 启用两步验证
+		recoveryCodes, err := controller.userService.ActivateTOTP(claims.UID, reqBody.Code)
+		if errors.Is(err, services.ErrTOTPCodeInvalid) || errors.Is(err, services.ErrTOTPAlreadyEnabled) ||
+			errors.Is(err, services.ErrTOTPNotEnrolled) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewRecoveryCodesData(recoveryCodes)),
+		)
+	}
+}
+
+// NewDisableTOTPHandler 返回关闭两步验证的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的关闭两步验证的处理函数。
+func (controller *UserController) NewDisableTOTPHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 解析请求体
+		reqBody := new(types.UserTOTPDisableBody)
+		err := ctx.BodyParser(reqBody)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 校验参数
+		if reqBody.Password == "" || reqBody.Code == "" {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "password or code is required"),
+			)
+		}
+
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 关闭两步验证
+		err = controller.userService.DisableTOTP(claims.UID, reqBody.Password, reqBody.Code)
+		if errors.Is(err, services.ErrPasswordIncorrect) || errors.Is(err, services.ErrTOTPCodeInvalid) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.AUTH_ERROR, err.Error()),
+			)
+		}
+		if errors.Is(err, services.ErrTOTPNotEnabled) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed"),
+		)
+	}
+}
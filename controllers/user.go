@@ -19,13 +19,16 @@ import (
 	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
 	"github.com/Kirisakiii/neko-micro-blog-backend/models"
 	"github.com/Kirisakiii/neko-micro-blog-backend/services"
+	"github.com/Kirisakiii/neko-micro-blog-backend/storages"
 	"github.com/Kirisakiii/neko-micro-blog-backend/types"
 	"github.com/Kirisakiii/neko-micro-blog-backend/utils/serializers"
 )
 
 // UserController 用户控制器
 type UserController struct {
-	userService *services.UserService
+	userService     *services.UserService
+	relationService *services.RelationService
+	mediaStorage    storages.MediaStorage
 }
 
 // NewUserController 返回一个新的 UserController 实例。
@@ -34,7 +37,9 @@ type UserController struct {
 //   - *UserController：新的 UserController 实例。
 func (factory *Factory) NewUserController() *UserController {
 	return &UserController{
-		userService: factory.serviceFactory.NewUserService(),
+		userService:     factory.serviceFactory.NewUserService(),
+		relationService: factory.serviceFactory.NewRelationService(),
+		mediaStorage:    factory.mediaStorage,
 	}
 }
 
@@ -89,9 +94,18 @@ func (controller *UserController) NewProfileHandler() fiber.Handler {
 			)
 		}
 
+		// 获取关系统计
+		viewerUID := getViewerUID(ctx)
+		stats, err := controller.relationService.GetUserRelationStats(uint64(user.ID), viewerUID)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
 		// 返回结果
 		return ctx.Status(200).JSON(
-			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewUserProfileData(user)),
+			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewUserProfileData(user, stats, viewerUID, controller.mediaStorage)),
 		)
 	}
 }
@@ -120,6 +134,11 @@ func (controller *UserController) NewRegisterHandler() fiber.Handler {
 
 		// 注册用户
 		err = controller.userService.RegisterUser(reqBody.Username, reqBody.Password)
+		if errors.Is(err, services.ErrUsernameExists) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
 		if err != nil {
 			return ctx.Status(200).JSON(
 				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
@@ -156,29 +175,298 @@ func (controller *UserController) NewLoginHandler() fiber.Handler {
 		}
 
 		// 解析 UA
-		userAgentString := ctx.Get("User-Agent")
-		ua := useragent.New(userAgentString)
-		// 获取浏览器信息
-		browser, version := ua.Browser()
-		var sb strings.Builder
-		sb.WriteString(browser)
-		sb.WriteString(" ")
-		sb.WriteString(version)
-		browserInfo := sb.String()
-		// 获取操作系统信息
-		os := ua.OSInfo().FullName
+		browserInfo, os := parseLoginClient(ctx)
 
 		// 登陆
-		token, err := controller.userService.LoginUser(reqBody.Username, reqBody.Password, ctx.IP(), browserInfo, os)
+		tokenPair, challenge, err := controller.userService.LoginUser(reqBody.Username, reqBody.Password, ctx.IP(), browserInfo, os)
+		var lockedErr *services.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			return newLoginLockedResponse(ctx, lockedErr)
+		}
 		if err != nil {
 			return ctx.Status(200).JSON(
 				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
 			)
 		}
 
+		// 需要两步验证
+		if challenge != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SUCCESS, "two-factor authentication required", serializers.NewLoginChallengeData(challenge)),
+			)
+		}
+
 		// 返回结果
 		return ctx.Status(200).JSON(
-			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewUserToken(token)),
+			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewUserToken(tokenPair)),
+		)
+	}
+}
+
+// parseLoginClient 从 User-Agent 中解析登录应用与设备。
+//
+// 参数：
+//   - ctx：Fiber 上下文
+//
+// 返回值：
+//   - string：登录应用，如 Chrome 236.12
+//   - string：登录设备，如 Windows 10
+func parseLoginClient(ctx *fiber.Ctx) (string, string) {
+	ua := useragent.New(ctx.Get("User-Agent"))
+	// 获取浏览器信息
+	browser, version := ua.Browser()
+	var sb strings.Builder
+	sb.WriteString(browser)
+	sb.WriteString(" ")
+	sb.WriteString(version)
+	// 获取操作系统信息
+	return sb.String(), ua.OSInfo().FullName
+}
+
+// newLoginLockedResponse 返回登录被暂时锁定的响应。
+//
+// 参数：
+//   - ctx：Fiber 上下文
+//   - lockedErr：登录锁定错误
+//
+// 返回值：
+//   - error：写入响应时的错误
+func newLoginLockedResponse(ctx *fiber.Ctx, lockedErr *services.LoginLockedError) error {
+	lockedData := serializers.NewLoginLockedData(lockedErr.RetryAfter)
+	ctx.Set(fiber.HeaderRetryAfter, strconv.FormatInt(lockedData.RetryAfter, 10))
+	return ctx.Status(200).JSON(
+		serializers.NewResponse(consts.LOGIN_LOCKED, lockedErr.Error(), lockedData),
+	)
+}
+
+// NewRefreshTokenHandler 返回刷新令牌的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的刷新令牌的处理函数。
+func (controller *UserController) NewRefreshTokenHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 解析请求体
+		reqBody := new(types.UserRefreshTokenBody)
+		err := ctx.BodyParser(reqBody)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 校验参数
+		if reqBody.RefreshToken == "" {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "refresh token is required"),
+			)
+		}
+
+		// 刷新令牌
+		tokenPair, err := controller.userService.RefreshUserToken(reqBody.RefreshToken)
+		if errors.Is(err, services.ErrRefreshTokenInvalid) || errors.Is(err, services.ErrRefreshTokenReused) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.AUTH_ERROR, err.Error()),
+			)
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewUserToken(tokenPair)),
+		)
+	}
+}
+
+// NewLogoutHandler 返回注销当前会话的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的注销当前会话的处理函数。
+func (controller *UserController) NewLogoutHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 注销
+		err := controller.userService.LogoutUser(claims.SessionID)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed"),
+		)
+	}
+}
+
+// NewLogoutAllHandler 返回注销全部会话的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的注销全部会话的处理函数。
+func (controller *UserController) NewLogoutAllHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 注销全部会话
+		err := controller.userService.LogoutUserAll(claims.UID)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed"),
+		)
+	}
+}
+
+// NewSessionListHandler 返回获取会话列表的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的获取会话列表的处理函数。
+func (controller *UserController) NewSessionListHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 获取会话列表
+		sessions, err := controller.userService.GetUserSessions(claims.UID)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewUserSessionListResponse(sessions, claims.SessionID)),
+		)
+	}
+}
+
+// NewRevokeSessionHandler 返回撤销指定会话的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的撤销指定会话的处理函数。
+func (controller *UserController) NewRevokeSessionHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 获取会话ID
+		sessionID := ctx.Params("session")
+		if sessionID == "" {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "session id is required"),
+			)
+		}
+
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 撤销会话
+		err := controller.userService.RevokeUserSession(claims.UID, sessionID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "session does not exist"),
+			)
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed"),
+		)
+	}
+}
+
+// NewRevokeOtherSessionsHandler 返回撤销其他会话的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的撤销其他会话的处理函数。
+func (controller *UserController) NewRevokeOtherSessionsHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 撤销除当前会话外的所有会话
+		err := controller.userService.RevokeOtherUserSessions(claims.UID, claims.SessionID)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed"),
+		)
+	}
+}
+
+// NewLoginEventListHandler 返回获取未确认登录事件的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的获取未确认登录事件的处理函数。
+func (controller *UserController) NewLoginEventListHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 获取登录事件
+		events, err := controller.userService.GetUncheckedLoginEvents(claims.UID)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed", serializers.NewLoginEventListResponse(events)),
+		)
+	}
+}
+
+// NewAcknowledgeLoginEventsHandler 返回确认登录事件的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的确认登录事件的处理函数。
+func (controller *UserController) NewAcknowledgeLoginEventsHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 解析请求体
+		reqBody := new(types.UserLoginEventAckBody)
+		err := ctx.BodyParser(reqBody)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 确认登录事件
+		err = controller.userService.AcknowledgeLoginEvents(claims.UID, reqBody.IDs)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "succeed"),
 		)
 	}
 }
@@ -221,10 +509,10 @@ func (controller *UserController) NewUploadAvatarHandler() fiber.Handler {
 	}
 }
 
-//	NewUserUpdatePassword 修改密码的函数
+// NewUpdatePasswordHandler 返回修改密码的处理函数。
 //
 // 返回值：
-//   - fiber.Handler：新密码的处理函数。
+//   - fiber.Handler：新的修改密码的处理函数。
 func (controller *UserController) NewUpdatePasswordHandler() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		// 解析请求体
@@ -237,23 +525,38 @@ func (controller *UserController) NewUpdatePasswordHandler() fiber.Handler {
 		}
 
 		// 校验参数
-		if reqBody.Username == "" || reqBody.Password == "" || reqBody.NewPassword == ""{
+		if reqBody.Password == "" || reqBody.NewPassword == "" {
 			return ctx.Status(200).JSON(
-				serializers.NewResponse(consts.PARAMETER_ERROR, "username, password or new password is required"),
+				serializers.NewResponse(consts.PARAMETER_ERROR, "password or new password is required"),
 			)
 		}
 
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
 		// 修改密码
 		err = controller.userService.UserUpdatePassword(
-			reqBody.Username,
+			claims.UID,
+			claims.SessionID,
 			reqBody.Password,
 			reqBody.NewPassword,
+			reqBody.KeepCurrentSession,
 		)
-		if err != nil {
+		if errors.Is(err, services.ErrPasswordIncorrect) {
 			return ctx.Status(200).JSON(
 				serializers.NewResponse(consts.AUTH_ERROR, err.Error()),
 			)
 		}
+		if errors.Is(err, services.ErrPasswordInvalid) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
 
 		// 返回成功的 JSON 响应
 		return ctx.Status(200).JSON(
@@ -262,6 +565,173 @@ func (controller *UserController) NewUpdatePasswordHandler() fiber.Handler {
 	}
 }
 
+// NewUpdateEmailHandler 返回修改邮箱的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的修改邮箱的处理函数。
+func (controller *UserController) NewUpdateEmailHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 解析请求体
+		reqBody := new(types.UserUpdateEmailBody)
+		err := ctx.BodyParser(reqBody)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 获取Token Claims
+		claims := ctx.Locals("claims").(*types.BearerTokenClaims)
+
+		// 修改邮箱
+		err = controller.userService.UpdateUserEmail(claims.UID, reqBody.Email)
+		if errors.Is(err, services.ErrEmailInvalid) || errors.Is(err, services.ErrEmailInUse) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "verification token sent"),
+		)
+	}
+}
+
+// NewVerifyEmailHandler 返回验证邮箱的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的验证邮箱的处理函数。
+func (controller *UserController) NewVerifyEmailHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 解析请求体
+		reqBody := new(types.UserVerifyEmailBody)
+		err := ctx.BodyParser(reqBody)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 校验参数
+		if reqBody.Token == "" {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "token is required"),
+			)
+		}
+
+		// 验证邮箱
+		err = controller.userService.VerifyUserEmail(reqBody.Token)
+		if errors.Is(err, services.ErrOneTimeTokenInvalid) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.AUTH_ERROR, err.Error()),
+			)
+		}
+		if errors.Is(err, services.ErrEmailInUse) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "email verified"),
+		)
+	}
+}
+
+// NewRequestPasswordResetHandler 返回请求重置密码的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的请求重置密码的处理函数。
+func (controller *UserController) NewRequestPasswordResetHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 解析请求体
+		reqBody := new(types.UserPasswordResetRequestBody)
+		err := ctx.BodyParser(reqBody)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 请求重置密码
+		err = controller.userService.RequestPasswordReset(reqBody.Email)
+		if errors.Is(err, services.ErrEmailInvalid) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 无论邮箱是否存在均返回相同结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "if the email is verified, a reset token has been sent"),
+		)
+	}
+}
+
+// NewConfirmPasswordResetHandler 返回确认重置密码的处理函数。
+//
+// 返回值：
+//   - fiber.Handler：新的确认重置密码的处理函数。
+func (controller *UserController) NewConfirmPasswordResetHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 解析请求体
+		reqBody := new(types.UserPasswordResetConfirmBody)
+		err := ctx.BodyParser(reqBody)
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+
+		// 校验参数
+		if reqBody.Token == "" || reqBody.NewPassword == "" {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, "token or new password is required"),
+			)
+		}
+
+		// 重置密码
+		err = controller.userService.ResetPassword(reqBody.Token, reqBody.NewPassword)
+		if errors.Is(err, services.ErrOneTimeTokenInvalid) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.AUTH_ERROR, err.Error()),
+			)
+		}
+		if errors.Is(err, services.ErrPasswordInvalid) {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.PARAMETER_ERROR, err.Error()),
+			)
+		}
+		if err != nil {
+			return ctx.Status(200).JSON(
+				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
+			)
+		}
+
+		// 返回结果
+		return ctx.Status(200).JSON(
+			serializers.NewResponse(consts.SUCCESS, "password reset successfully"),
+		)
+	}
+}
+
 // NewUserUpdateProfileHandler 返回更新用户资料的处理函数。
 //
 // 返回值：
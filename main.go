@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"log"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/compress"
@@ -20,15 +22,24 @@ import (
 	"github.com/Kirisakiii/neko-micro-blog-backend/loggers"
 	"github.com/Kirisakiii/neko-micro-blog-backend/middlewares"
 	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/notifiers"
 	"github.com/Kirisakiii/neko-micro-blog-backend/rontines"
 	"github.com/Kirisakiii/neko-micro-blog-backend/services"
+	"github.com/Kirisakiii/neko-micro-blog-backend/storages"
 	"github.com/Kirisakiii/neko-micro-blog-backend/stores"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/parsers"
 )
 
 var (
 	logger            *logrus.Logger
 	cfg               *configs.Config
 	db                *gorm.DB
+	tokenSettings     *types.TokenSettings
+	passwordParams    *types.PasswordHashParams
+	timelineStrategy  string
+	notifier          notifiers.Notifier
+	mediaStorage      storages.MediaStorage
 	storeFactory      *stores.Factory
 	controllerFactory *controllers.Factory
 	middlewareFactory *middlewares.Factory
@@ -47,6 +58,46 @@ func init() {
 		logger.Panicln(err.Error())
 	}
 
+	// 加载令牌设置
+	tokenSettings, err = parsers.ParseTokenSettings(cfg)
+	if err != nil {
+		logger.Panicln("加载令牌设置失败：", err.Error())
+	}
+
+	// 加载密码哈希参数
+	passwordParams, err = parsers.ParsePasswordHashParams(cfg)
+	if err != nil {
+		logger.Panicln("加载密码哈希参数失败：", err.Error())
+	}
+
+	// 加载时间线生成策略
+	timelineStrategy, err = parsers.ParseTimelineStrategy(cfg)
+	if err != nil {
+		logger.Panicln("加载时间线设置失败：", err.Error())
+	}
+
+	// 校验回收站设置
+	if cfg.Trash.RetentionDays <= 0 {
+		logger.Panicln("回收站保留天数必须为正数")
+	}
+
+	// 创建通知发送器
+	notifier, err = notifiers.NewNotifier(cfg, logger)
+	if err != nil {
+		logger.Panicln("创建通知发送器失败：", err.Error())
+	}
+
+	// 创建媒体文件存储
+	mediaStorage, err = storages.NewMediaStorage(cfg)
+	if err != nil {
+		logger.Panicln("创建媒体文件存储失败：", err.Error())
+	}
+	// 确保默认头像存在
+	err = storages.EnsureObject(mediaStorage, storages.AvatarKey("vanilla.webp"), "./public/avatars/vanilla.webp", "image/webp")
+	if err != nil {
+		logger.Panicln("初始化默认头像失败：", err.Error())
+	}
+
 	// 设置日志等级
 	var (
 		logLevel logrus.Level
@@ -81,6 +132,8 @@ func init() {
 		)),
 		&gorm.Config{
 			Logger: gormLogger.Default.LogMode(logMode),
+			// 将唯一约束冲突等数据库错误转换为 gorm.ErrDuplicatedKey 等通用错误
+			TranslateError: true,
 		},
 	)
 	if err != nil {
@@ -90,21 +143,22 @@ func init() {
 
 	// 迁移模型
 	logger.Debugln("正在迁移数据表模型...")
-	err = models.Migrate(db)
+	err = models.Migrate(db, logger)
 	if err != nil {
 		logger.Panicln("迁移数据库模型失败：", err.Error())
 	}
 
 	// 建立数据访问层工厂
-	storeFactory = stores.NewFactory(db)
+	storeFactory = stores.NewFactory(db, mediaStorage)
 
 	// 建立控制器层工厂
 	controllerFactory = controllers.NewFactory(
-		services.NewFactory(storeFactory),
+		services.NewFactory(storeFactory, tokenSettings, passwordParams, timelineStrategy, notifier),
+		mediaStorage,
 	)
 
 	// 建立中间件工厂
-	middlewareFactory = middlewares.NewFactory(storeFactory)
+	middlewareFactory = middlewares.NewFactory(storeFactory, tokenSettings)
 }
 
 func main() {
@@ -115,7 +169,18 @@ func main() {
 		cron.NewChain(
 			cron.SkipIfStillRunning(cron.DefaultLogger),
 		).Then(
-			rontines.NewAvatarCleanerJob(logger, db),
+			rontines.NewAvatarCleanerJob(logger, db, mediaStorage),
+		),
+	)
+	if err != nil {
+		logger.Panicln(err.Error())
+	}
+	_, err = crontab.AddJob(
+		"@every 1h",
+		cron.NewChain(
+			cron.SkipIfStillRunning(cron.DefaultLogger),
+		).Then(
+			rontines.NewTrashPurgeJob(logger, db, mediaStorage, time.Duration(cfg.Trash.RetentionDays)*24*time.Hour),
 		),
 	)
 	if err != nil {
@@ -145,16 +210,22 @@ func main() {
 	// Auth 中间件
 	authMiddleware := middlewareFactory.NewTokenAuthMiddleware()
 
-	// 静态资源路由
-	resource := app.Group("/resources")
-	// 头像资源路由
-	resource.Static("/avatar", "./public/avatars", fiber.Static{
-		Compress: true,
-	})
-	// 博文图片资源路由
-	resource.Static("/image", "./public/images", fiber.Static{
-		Compress: true,
-	})
+	// 静态资源路由，仅在使用本地存储时由服务器提供媒体文件
+	if localStorage, ok := mediaStorage.(*storages.LocalStorage); ok {
+		resource := app.Group(localStorage.BaseURL())
+		// 媒体文件资源路由
+		resource.Static("/", localStorage.Root(), fiber.Static{
+			Compress: true,
+		})
+		// 兼容旧版头像资源路由
+		resource.Static("/avatar", filepath.Join(localStorage.Root(), "avatars"), fiber.Static{
+			Compress: true,
+		})
+		// 兼容旧版博文图片资源路由
+		resource.Static("/image", filepath.Join(localStorage.Root(), "images"), fiber.Static{
+			Compress: true,
+		})
+	}
 
 	// api 路由
 	api := app.Group("/api")
@@ -162,20 +233,59 @@ func main() {
 	// User 路由
 	userController := controllerFactory.NewUserController()
 	user := api.Group("/user")
-	user.Get("/profile", userController.NewProfileHandler())                                             // 查询用户信息
-	user.Post("/register", userController.NewRegisterHandler())                                          // 用户注册
-	user.Post("/login", userController.NewLoginHandler())                                                // 用户登录
-	user.Post("/upload-avatar", authMiddleware.NewMiddleware(), userController.NewUploadAvatarHandler()) // 上传头像
-	user.Post("/update-psw", userController.NewUpdatePasswordHandler())                                  // 修改密码
-	user.Post("/edit", authMiddleware.NewMiddleware(), userController.NewUpdateProfileHandler())         // 修改用户资料
+	user.Get("/profile", authMiddleware.NewOptionalMiddleware(), userController.NewProfileHandler())                     // 查询用户信息
+	user.Post("/register", userController.NewRegisterHandler())                                                          // 用户注册
+	user.Post("/login", userController.NewLoginHandler())                                                                // 用户登录
+	user.Post("/login/2fa", userController.NewTOTPLoginHandler())                                                        // 两步验证登录
+	user.Post("/refresh", userController.NewRefreshTokenHandler())                                                       // 刷新令牌
+	user.Post("/upload-avatar", authMiddleware.NewMiddleware(), userController.NewUploadAvatarHandler())                 // 上传头像
+	user.Post("/update-psw", authMiddleware.NewMiddleware(), userController.NewUpdatePasswordHandler())                  // 修改密码
+	user.Post("/edit", authMiddleware.NewMiddleware(), userController.NewUpdateProfileHandler())                         // 修改用户资料
+	user.Post("/email", authMiddleware.NewMiddleware(), userController.NewUpdateEmailHandler())                          // 修改邮箱
+	user.Post("/email/verify", userController.NewVerifyEmailHandler())                                                   // 验证邮箱
+	user.Post("/password-reset/request", userController.NewRequestPasswordResetHandler())                                // 请求重置密码
+	user.Post("/password-reset/confirm", userController.NewConfirmPasswordResetHandler())                                // 确认重置密码
+	user.Post("/logout", authMiddleware.NewMiddleware(), userController.NewLogoutHandler())                              // 注销当前会话
+	user.Post("/logout-all", authMiddleware.NewMiddleware(), userController.NewLogoutAllHandler())                       // 注销全部会话
+	user.Get("/sessions", authMiddleware.NewMiddleware(), userController.NewSessionListHandler())                        // 获取会话列表
+	user.Post("/sessions/revoke/:session", authMiddleware.NewMiddleware(), userController.NewRevokeSessionHandler())     // 撤销指定会话
+	user.Post("/sessions/revoke-others", authMiddleware.NewMiddleware(), userController.NewRevokeOtherSessionsHandler()) // 撤销其他会话
+	user.Get("/login-events", authMiddleware.NewMiddleware(), userController.NewLoginEventListHandler())                 // 获取未确认的登录事件
+	user.Post("/login-events/ack", authMiddleware.NewMiddleware(), userController.NewAcknowledgeLoginEventsHandler())    // 确认登录事件
+	user.Post("/2fa/enroll", authMiddleware.NewMiddleware(), userController.NewEnrollTOTPHandler())                      // 注册两步验证
+	user.Post("/2fa/activate", authMiddleware.NewMiddleware(), userController.NewActivateTOTPHandler())                  // 启用两步验证
+	user.Post("/2fa/disable", authMiddleware.NewMiddleware(), userController.NewDisableTOTPHandler())                    // 关闭两步验证
+
+	// 用户关系路由
+	relationController := controllerFactory.NewRelationController()
+	user.Post("/follow/:uid", authMiddleware.NewMiddleware(), relationController.NewFollowHandler())     // 关注用户
+	user.Post("/unfollow/:uid", authMiddleware.NewMiddleware(), relationController.NewUnfollowHandler()) // 取消关注用户
+	user.Post("/block/:uid", authMiddleware.NewMiddleware(), relationController.NewBlockHandler())       // 屏蔽用户
+	user.Post("/unblock/:uid", authMiddleware.NewMiddleware(), relationController.NewUnblockHandler())   // 解除屏蔽用户
+	user.Get("/followers", relationController.NewFollowerListHandler())                                  // 获取粉丝列表
+	user.Get("/following", relationController.NewFollowingListHandler())                                 // 获取关注列表
 
 	//post 路由
 	postController := controllerFactory.NewPostController()
 	post := api.Group("/post")
-	post.Post("/new", authMiddleware.NewMiddleware(), postController.NewCreatePostHandler())            // 创建文章
-	post.Get("/list", postController.NewPostListHandler())                                              // 获取文章列表
-	post.Get("/detail", postController.NewPostDetailHandler())                                          // 获取文章信息
-	post.Delete("/delete/:post", authMiddleware.NewMiddleware(), postController.NewDeletePostHandler()) // 删除文章
+	post.Post("/new", authMiddleware.NewMiddleware(), postController.NewCreatePostHandler())                        // 创建文章
+	post.Get("/list", authMiddleware.NewOptionalMiddleware(), postController.NewPostListHandler())                  // 获取文章列表
+	post.Get("/timeline", authMiddleware.NewMiddleware(), postController.NewTimelineHandler())                      // 获取首页时间线
+	post.Get("/detail", authMiddleware.NewOptionalMiddleware(), postController.NewPostDetailHandler())              // 获取文章信息
+	post.Post("/edit/:post", authMiddleware.NewMiddleware(), postController.NewEditPostHandler())                   // 编辑文章
+	post.Get("/revisions/:post", authMiddleware.NewMiddleware(), postController.NewPostRevisionListHandler())       // 获取文章修订记录
+	post.Post("/forward", authMiddleware.NewMiddleware(), postController.NewForwardPostHandler())                   // 转发文章
+	post.Post("/visibility/:post", authMiddleware.NewMiddleware(), postController.NewUpdatePostVisibilityHandler()) // 修改文章可见性
+	post.Delete("/delete/:post", authMiddleware.NewMiddleware(), postController.NewDeletePostHandler())             // 删除文章
+	post.Get("/trash", authMiddleware.NewMiddleware(), postController.NewTrashedPostListHandler())                  // 获取回收站文章列表
+	post.Post("/restore/:post", authMiddleware.NewMiddleware(), postController.NewRestorePostHandler())             // 恢复文章
+	post.Post("/like/:post", authMiddleware.NewMiddleware(), postController.NewLikePostHandler())                   // 点赞文章
+	post.Post("/unlike/:post", authMiddleware.NewMiddleware(), postController.NewUnlikePostHandler())               // 取消点赞文章
+	post.Post("/favorite/:post", authMiddleware.NewMiddleware(), postController.NewFavoritePostHandler())           // 收藏文章
+	post.Post("/unfavorite/:post", authMiddleware.NewMiddleware(), postController.NewUnfavoritePostHandler())       // 取消收藏文章
+
+	// 用户主页博文路由
+	user.Get("/:uid/posts", authMiddleware.NewOptionalMiddleware(), postController.NewUserPostListHandler()) // 获取用户主页博文列表
 
 	// Comment 路由
 	commentController := controllerFactory.NewCommentController()
@@ -183,8 +293,14 @@ func main() {
 	comment.Post("/new", authMiddleware.NewMiddleware(), commentController.NewCreateCommentHandler(storeFactory.NewPostStore(), storeFactory.NewUserStore())) // 创建评论
 	comment.Post("/edit", authMiddleware.NewMiddleware(), commentController.NewUpdateCommentHandler())                                                        // 修改评论
 	comment.Post("/delete", authMiddleware.NewMiddleware(), commentController.DeleteCommentHandler())                                                         // 删除评论
-	comment.Get("/list", commentController.NewCommentListHandler())                                                                                           // 获取评论列表
-	comment.Get("/detail", commentController.NewCommentDetailHandler())
+	comment.Get("/trash", authMiddleware.NewMiddleware(), commentController.NewTrashedCommentListHandler())                                                   // 获取回收站评论列表
+	comment.Post("/restore", authMiddleware.NewMiddleware(), commentController.NewRestoreCommentHandler())                                                    // 恢复评论
+	comment.Get("/list", authMiddleware.NewOptionalMiddleware(), commentController.NewCommentListHandler())                                                   // 获取评论列表
+	comment.Get("/replies", authMiddleware.NewOptionalMiddleware(), commentController.NewReplyListHandler())                                                  // 获取评论回复列表
+	comment.Get("/detail", authMiddleware.NewOptionalMiddleware(), commentController.NewCommentDetailHandler())                                               // 获取评论信息
+	comment.Post("/like", authMiddleware.NewMiddleware(), commentController.NewLikeCommentHandler())                                                          // 点赞评论
+	comment.Post("/dislike", authMiddleware.NewMiddleware(), commentController.NewDislikeCommentHandler())                                                    // 点踩评论
+	comment.Post("/clear-reaction", authMiddleware.NewMiddleware(), commentController.NewClearCommentReactionHandler())                                       // 清除评论点赞或点踩
 
 	// 启动服务器
 	log.Fatal(app.Listen(fmt.Sprintf("%s:%d", cfg.Database.Host, cfg.Server.Port)))
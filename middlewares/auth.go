@@ -7,18 +7,23 @@ Copyright (c) [2024], Author(s):
 package middlewares
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
-	"github.com/Kirisakiii/neko-micro-blog-backend/stores"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
 	"github.com/Kirisakiii/neko-micro-blog-backend/utils/parsers"
 	"github.com/Kirisakiii/neko-micro-blog-backend/utils/serializers"
 	"github.com/Kirisakiii/neko-micro-blog-backend/utils/validers"
 )
 
 // TokenAuthMiddleware 认证中间件
+//
+// 访问令牌有效期较短，校验时仅检查签名与有效期，不查询数据库。
+// 会话被撤销后，已签发的访问令牌在过期前仍然有效，但无法再被刷新。
 type TokenAuthMiddleware struct {
-	userStore *stores.UserStore
+	tokenSettings *types.TokenSettings
 }
 
 // NewTokenAuthMiddleware 返回一个新的 AuthMiddleware 实例。
@@ -26,7 +31,7 @@ type TokenAuthMiddleware struct {
 // 返回值
 //   - *AuthMiddleware：新的 AuthMiddleware 实例。
 func (factory *Factory) NewTokenAuthMiddleware() *TokenAuthMiddleware {
-	return &TokenAuthMiddleware{userStore: factory.store.NewUserStore()}
+	return &TokenAuthMiddleware{tokenSettings: factory.tokenSettings}
 }
 
 // NewMiddleware Token 认证中间件
@@ -39,44 +44,45 @@ func (factory *Factory) NewTokenAuthMiddleware() *TokenAuthMiddleware {
 func (middleware *TokenAuthMiddleware) NewMiddleware() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		// 从请求头中获取 Token
-		token := ctx.Get("Authorization")
-		if token == "" {
+		if ctx.Get("Authorization") == "" {
 			return ctx.Status(200).JSON(
 				serializers.NewResponse(consts.PARAMETER_ERROR, "bearer token is required"),
 			)
 		}
-		if len(token) < 7 || token[:7] != "Bearer " {
-			return ctx.Status(200).JSON(
-				serializers.NewResponse(consts.PARAMETER_ERROR, "bearer token is invalid"),
-			)
-		}
-		token = token[7:]
 
 		// 验证 Token
-		claims, err := parsers.ParseToken(token)
+		claims, code, err := middleware.verifyToken(ctx)
 		if err != nil {
 			return ctx.Status(200).JSON(
-				serializers.NewResponse(consts.AUTH_ERROR, err.Error()),
+				serializers.NewResponse(code, err.Error()),
 			)
 		}
 
-		// 检验 Token 是否在有效期内
-		if !validers.ValideTokenClaims(claims) {
-			return ctx.Status(200).JSON(
-				serializers.NewResponse(consts.AUTH_ERROR, "bearer token is expired"),
-			)
+		// 将 claims 信息存入 ctx.Locals 中
+		ctx.Locals("claims", claims)
+
+		return ctx.Next()
+	}
+}
+
+// NewOptionalMiddleware 可选 Token 认证中间件
+//
+// 请求未携带 Token 时以匿名身份放行，携带 Token 时与 NewMiddleware 一样进行校验。
+//
+// 返回值
+//   - fiber.Handler：中间件处理函数
+func (middleware *TokenAuthMiddleware) NewOptionalMiddleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// 匿名访问直接放行
+		if ctx.Get("Authorization") == "" {
+			return ctx.Next()
 		}
 
-		// 检验 Token 是否可用
-		isAvaliable, err := middleware.userStore.IsUserTokenAvaliable(token)
+		// 验证 Token
+		claims, code, err := middleware.verifyToken(ctx)
 		if err != nil {
 			return ctx.Status(200).JSON(
-				serializers.NewResponse(consts.SERVER_ERROR, err.Error()),
-			)
-		}
-		if !isAvaliable {
-			return ctx.Status(200).JSON(
-				serializers.NewResponse(consts.AUTH_ERROR, "bearer token is not avaliable"),
+				serializers.NewResponse(code, err.Error()),
 			)
 		}
 
@@ -86,3 +92,33 @@ func (middleware *TokenAuthMiddleware) NewMiddleware() fiber.Handler {
 		return ctx.Next()
 	}
 }
+
+// verifyToken 解析并校验请求头中的 Bearer Token
+//
+// 参数
+//   - ctx：Fiber 上下文。
+//
+// 返回值
+//   - *types.BearerTokenClaims：Token 的声明
+//   - serializers.ResponseCode：校验失败时应返回的响应码
+//   - error：校验失败时返回相应的错误信息，否则返回nil
+func (middleware *TokenAuthMiddleware) verifyToken(ctx *fiber.Ctx) (*types.BearerTokenClaims, serializers.ResponseCode, error) {
+	token := ctx.Get("Authorization")
+	if len(token) < 7 || token[:7] != "Bearer " {
+		return nil, consts.PARAMETER_ERROR, errors.New("bearer token is invalid")
+	}
+	token = token[7:]
+
+	// 验证 Token
+	claims, err := parsers.ParseToken(middleware.tokenSettings, token)
+	if err != nil {
+		return nil, consts.AUTH_ERROR, err
+	}
+
+	// 检验 Token 是否在有效期内
+	if !validers.ValideTokenClaims(claims) {
+		return nil, consts.AUTH_ERROR, errors.New("bearer token is expired")
+	}
+
+	return claims, consts.SUCCESS, nil
+}
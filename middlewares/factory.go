@@ -6,12 +6,16 @@ Copyright (c) [2024], Author(s):
 */
 package middlewares
 
-import "github.com/Kirisakiii/neko-micro-blog-backend/stores"
+import (
+	"github.com/Kirisakiii/neko-micro-blog-backend/stores"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+)
 
 type Factory struct {
-	store *stores.Factory
+	store         *stores.Factory
+	tokenSettings *types.TokenSettings
 }
 
-func NewFactory(store *stores.Factory) *Factory {
-	return &Factory{store: store}
+func NewFactory(store *stores.Factory, tokenSettings *types.TokenSettings) *Factory {
+	return &Factory{store: store, tokenSettings: tokenSettings}
 }
@@ -15,13 +15,15 @@ import (
 type CommentInfo struct {
 	gorm.Model               // 基本模型
 	PostID     uint64        `gorm:"column:post_id"`                // 博文ID
+	ParentID   *uint64       `gorm:"column:parent_id;index"`        // 回复的评论ID
+	RootID     *uint64       `gorm:"column:root_id;index"`          // 所属楼层的根评论ID
 	UID        uint64        `gorm:"column:uid"`                    // 用户ID
 	Username   string        `gorm:"column:username"`               // 用户名
 	Content    string        `gorm:"column:content"`                // 内容
 	Like       pq.Int64Array `gorm:"column:like;type:bigint[]"`     // 点赞数 记录UID
 	Dislike    pq.Int64Array `gorm:"column:dislike;type:bigint[]"`  // 踩数 记录UID
+	ReplyCount uint64        `gorm:"column:reply_count;default:0"`  // 回复数
 	IsPublic   bool          `gorm:"column:is_public;default:true"` // 是否公开
+	DeletedBy  *uint64       `gorm:"column:deleted_by"`             // 执行删除的用户ID，未删除时为 nil
 	// Share   uint64 `gorm:"column:share"`                         // 分享数 暂时不实现
 }
-
-
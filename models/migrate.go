@@ -6,16 +6,22 @@ Copyright (c) [2024], Author(s):
 */
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
 
 // Migrate 数据库迁移
 //
 // 参数：
-//	- db *gorm.DB 数据库连接
+//   - db *gorm.DB 数据库连接
+//   - logger *logrus.Logger 日志记录器
 //
 // 返回值：
-//	- error 错误
-func Migrate(db *gorm.DB) error {
+//   - error 错误
+func Migrate(db *gorm.DB, logger *logrus.Logger) error {
 	var err error
 	// cron 相关
 	if err = db.AutoMigrate(&AvatarDeletionWaitList{}); err != nil {
@@ -32,19 +38,139 @@ func Migrate(db *gorm.DB) error {
 	if err = db.AutoMigrate(&UserLoginLog{}); err != nil {
 		return err
 	}
-	if err = db.AutoMigrate(&UserAvaliableToken{}); err != nil {
+	if err = db.AutoMigrate(&LoginAttemptCounter{}); err != nil {
+		return err
+	}
+	if err = db.AutoMigrate(&UserRefreshToken{}); err != nil {
+		return err
+	}
+	if err = db.AutoMigrate(&UserOneTimeToken{}); err != nil {
+		return err
+	}
+	if err = db.AutoMigrate(&UserRecoveryCode{}); err != nil {
+		return err
+	}
+	if err = db.AutoMigrate(&UserFollow{}); err != nil {
+		return err
+	}
+	if err = db.AutoMigrate(&UserBlock{}); err != nil {
 		return err
 	}
 
 	// Post 相关
+	// 可见性列新增前仅以 is_public 区分公开与私密，新增后需据此回填，避免私密博文被默认值公开
+	backfillVisibility := db.Migrator().HasTable(&PostInfo{}) && !db.Migrator().HasColumn(&PostInfo{}, "Visibility")
 	if err = db.AutoMigrate(&PostInfo{}); err != nil {
 		return err
 	}
+	if backfillVisibility {
+		err = db.Exec("UPDATE post_infos SET visibility = 'private' WHERE is_public = false").Error
+		if err != nil {
+			return err
+		}
+	}
+	if err = db.AutoMigrate(&PostRevision{}); err != nil {
+		return err
+	}
+
+	// Timeline 相关
+	if err = db.AutoMigrate(&TimelineEntry{}); err != nil {
+		return err
+	}
 
 	// Comment 相关
 	if err = db.AutoMigrate(&CommentInfo{}); err != nil {
 		return err
 	}
 
+	// 外键约束
+	if err = migrateForeignKeys(db, logger); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// foreignKey 外键约束定义
+type foreignKey struct {
+	model     interface{} // 所属模型
+	name      string      // 约束名
+	table     string      // 所属表
+	column    string      // 外键列
+	refTable  string      // 引用表
+	refColumn string      // 引用列
+	tombstone bool        // 是否将孤立记录移入回收站而非直接删除
+}
+
+// foreignKeys 需要创建的外键约束
+//
+// 博文与评论均为软删除，引用行仅在回收站清理时才会被彻底删除，此时依赖它的记录也应一并删除。
+// 评论属于用户内容，孤立的评论移入回收站保留至清理期限，其余可重建的记录直接删除。
+var foreignKeys = []foreignKey{
+	{&CommentInfo{}, "fk_comment_infos_post", "comment_infos", "post_id", "post_infos", "id", true},
+	{&PostRevision{}, "fk_post_revisions_post", "post_revisions", "post_id", "post_infos", "id", false},
+	{&TimelineEntry{}, "fk_timeline_entries_post", "timeline_entries", "post_id", "post_infos", "id", false},
+	{&UserFollow{}, "fk_user_follows_follower", "user_follows", "follower_uid", "user_infos", "id", false},
+	{&UserFollow{}, "fk_user_follows_followee", "user_follows", "followee_uid", "user_infos", "id", false},
+	{&UserBlock{}, "fk_user_blocks_blocker", "user_blocks", "blocker_uid", "user_infos", "id", false},
+	{&UserBlock{}, "fk_user_blocks_blocked", "user_blocks", "blocked_uid", "user_infos", "id", false},
+}
+
+// migrateForeignKeys 创建尚不存在的外键约束。
+//
+// 创建约束前会处理引用已不存在的孤立记录，这些记录来自于约束创建前的硬删除，处理的记录数会写入日志。
+// 移入回收站的孤立记录仍保留原引用，因此对应约束以 NOT VALID 创建，仅校验此后写入的记录。
+//
+// 参数：
+//   - db *gorm.DB 数据库连接
+//   - logger *logrus.Logger 日志记录器
+//
+// 返回值：
+//   - error 错误
+func migrateForeignKeys(db *gorm.DB, logger *logrus.Logger) error {
+	for _, fk := range foreignKeys {
+		if db.Migrator().HasConstraint(fk.model, fk.name) {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			orphanCondition := fk.column + " IS NOT NULL AND NOT EXISTS " +
+				"(SELECT 1 FROM " + fk.refTable + " WHERE " + fk.refTable + "." + fk.refColumn + " = " + fk.table + "." + fk.column + ")"
+
+			// 处理引用已不存在的记录
+			var result *gorm.DB
+			constraintOption := ""
+			if fk.tombstone {
+				// 移入回收站，删除者记为系统，到期后由回收站清理任务彻底删除
+				result = tx.Exec(
+					"UPDATE "+fk.table+" SET deleted_at = CAST(? AS timestamptz), deleted_by = 0 WHERE deleted_at IS NULL AND "+orphanCondition,
+					time.Now(),
+				)
+				constraintOption = " NOT VALID"
+			} else {
+				result = tx.Exec("DELETE FROM " + fk.table + " WHERE " + orphanCondition)
+			}
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				if fk.tombstone {
+					logger.Warningf("创建外键约束 %s 前已将 %s 中 %d 条孤立记录移入回收站", fk.name, fk.table, result.RowsAffected)
+				} else {
+					logger.Warningf("创建外键约束 %s 前已删除 %s 中 %d 条孤立记录", fk.name, fk.table, result.RowsAffected)
+				}
+			}
+
+			// 创建外键约束
+			return tx.Exec(
+				"ALTER TABLE " + fk.table + " ADD CONSTRAINT " + fk.name +
+					" FOREIGN KEY (" + fk.column + ") REFERENCES " + fk.refTable + " (" + fk.refColumn + ") ON DELETE CASCADE" + constraintOption,
+			).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -7,22 +7,42 @@ Copyright (c) [2024], Author(s):
 package models
 
 import (
+	"time"
+
 	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
 // PostInfo 博文信息模型
 type PostInfo struct {
-	gorm.Model                  // 基本模型
-	ParentPostID *uint64        `gorm:"column:parent_post_id"`         // 转发自文章ID
-	UID          uint64         `gorm:"column:uid"`                    // 用户ID
-	IpAddrress   *string        `gorm:"column:ip_address"`             // IP地址
-	Title        string         `gorm:"column:title"`                  // 标题
-	Content      string         `gorm:"column:content"`                // 内容
-	Images       pq.StringArray `gorm:"column:images;type:text[]"`     // 图片
-	Like         pq.Int64Array  `gorm:"column:like;type:bigint[]"`     // 点赞数 记录UID
-	Favorite     pq.Int64Array  `gorm:"column:favorite;type:bigint[]"` // 收藏数 记录UID
-	Farward      pq.Int64Array  `gorm:"column:farward;type:bigint[]"`  // 转发数 记录UID
-	IsPublic     bool           `gorm:"column:is_public;default:true"` // 是否公开
+	gorm.Model                   // 基本模型
+	ParentPostID  *uint64        `gorm:"column:parent_post_id"`            // 转发自文章ID
+	UID           uint64         `gorm:"column:uid"`                       // 用户ID
+	IpAddrress    *string        `gorm:"column:ip_address"`                // IP地址
+	Title         string         `gorm:"column:title"`                     // 标题
+	Content       string         `gorm:"column:content"`                   // 内容
+	Images        pq.StringArray `gorm:"column:images;type:text[]"`        // 图片
+	Like          pq.Int64Array  `gorm:"column:like;type:bigint[]"`        // 点赞数 记录UID
+	Favorite      pq.Int64Array  `gorm:"column:favorite;type:bigint[]"`    // 收藏数 记录UID
+	Farward       pq.Int64Array  `gorm:"column:farward;type:bigint[]"`     // 转发数 记录UID
+	IsPublic      bool           `gorm:"column:is_public;default:true"`    // 是否公开
+	Visibility    string         `gorm:"column:visibility;default:public"` // 可见性 public followers private
+	EditedAt      *time.Time     `gorm:"column:edited_at"`                 // 最近一次编辑时间，未编辑过时为 nil
+	RevisionCount uint64         `gorm:"column:revision_count;default:0"`  // 修订次数
+	DeletedBy     *uint64        `gorm:"column:deleted_by"`                // 执行删除的用户ID，未删除时为 nil
 	// Share     uint64 `gorm:"column:share"`                          // 分享数 暂时不实现
 }
+
+// PostRevision 博文修订记录模型
+//
+// 每次编辑博文时保存编辑前的完整快照，与当前博文或相邻修订对比即可得到每次编辑的差异。
+type PostRevision struct {
+	gorm.Model                // 基本模型，创建时间即该版本被替换的时间
+	PostID     uint64         `gorm:"index;column:post_id"`      // 博文ID
+	Revision   uint64         `gorm:"column:revision"`           // 修订序号，从 1 开始
+	EditorUID  uint64         `gorm:"column:editor_uid"`         // 执行编辑的用户ID
+	Title      string         `gorm:"column:title"`              // 编辑前的标题
+	Content    string         `gorm:"column:content"`            // 编辑前的内容
+	Images     pq.StringArray `gorm:"column:images;type:text[]"` // 编辑前的图片
+	Visibility string         `gorm:"column:visibility"`         // 编辑前的可见性
+}
@@ -0,0 +1,29 @@
+/*
+Package models - NekoBlog backend server database models
+This file is for user relationship related models.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package models
+
+import "time"
+
+// UserFollow 用户关注关系模型
+//
+// 关注关系取消时直接删除记录，因此不使用软删除，以便唯一索引在重新关注时仍然有效。
+type UserFollow struct {
+	ID          uint64    `gorm:"primarykey;column:id"`                                  // 关系ID
+	CreatedAt   time.Time `gorm:"column:created_at"`                                     // 关注时间
+	FollowerUID uint64    `gorm:"uniqueIndex:idx_user_follow;column:follower_uid"`       // 关注者用户ID
+	FolloweeUID uint64    `gorm:"uniqueIndex:idx_user_follow;index;column:followee_uid"` // 被关注者用户ID
+}
+
+// UserBlock 用户屏蔽关系模型
+//
+// 与关注关系相同，解除屏蔽时直接删除记录。
+type UserBlock struct {
+	ID         uint64    `gorm:"primarykey;column:id"`                                // 关系ID
+	CreatedAt  time.Time `gorm:"column:created_at"`                                   // 屏蔽时间
+	BlockerUID uint64    `gorm:"uniqueIndex:idx_user_block;column:blocker_uid"`       // 屏蔽者用户ID
+	BlockedUID uint64    `gorm:"uniqueIndex:idx_user_block;index;column:blocked_uid"` // 被屏蔽者用户ID
+}
@@ -0,0 +1,20 @@
+/*
+Package models - NekoBlog backend server database models
+This file is for timeline related models.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package models
+
+import "time"
+
+// TimelineEntry 时间线条目模型，仅在写扩散模式下使用
+//
+// 条目的创建时间与博文的创建时间一致，以便与读扩散模式使用相同的分页游标。
+type TimelineEntry struct {
+	ID        uint64    `gorm:"primarykey;column:id"`                                // 条目ID
+	UID       uint64    `gorm:"uniqueIndex:idx_timeline_entry;column:uid"`           // 时间线所属用户ID
+	PostID    uint64    `gorm:"uniqueIndex:idx_timeline_entry;index;column:post_id"` // 博文ID
+	AuthorUID uint64    `gorm:"index;column:author_uid"`                             // 博文作者用户ID
+	CreatedAt time.Time `gorm:"column:created_at"`                                   // 博文创建时间
+}
@@ -14,23 +14,28 @@ import (
 
 // UserInfo 用户信息模型
 type UserInfo struct {
-	gorm.Model            // 基本模型
-	UserName   string     `gorm:"unique;column:username"`             // 用户名
-	NickName   *string    `gorm:"column:nickname"`                    // 昵称
-	Avatar     string     `gorm:"default:vanilla.webp;column:avatar"` // 头像
-	Birth      *time.Time `gorm:"column:birth"`                       // 生日
-	Gender     *string    `gorm:"column:gender"`                      // 性别
-	Authority  uint64     `gorm:"default:0;column:authority"`         // 权限等级
-	Level      uint64     `gorm:"default:1;column:level"`             // 等级
+	gorm.Model               // 基本模型
+	UserName      string     `gorm:"unique;column:username"`              // 用户名
+	NickName      *string    `gorm:"column:nickname"`                     // 昵称
+	Avatar        string     `gorm:"default:vanilla.webp;column:avatar"`  // 头像
+	Birth         *time.Time `gorm:"column:birth"`                        // 生日
+	Gender        *string    `gorm:"column:gender"`                       // 性别
+	Authority     uint64     `gorm:"default:0;column:authority"`          // 权限等级
+	Level         uint64     `gorm:"default:1;column:level"`              // 等级
+	Email         *string    `gorm:"uniqueIndex;column:email"`            // 邮箱
+	EmailVerified bool       `gorm:"default:false;column:email_verified"` // 邮箱是否已验证
 }
 
 // UserAuthInfo 用户认证信息模型
 type UserAuthInfo struct {
-	gorm.Model          // 基本模型
-	UID          uint64 `gorm:"unique;column:uid"`      // 用户ID
-	UserName     string `gorm:"unique;column:username"` // 用户名
-	Salt         string `gorm:"column:salt"`            // 盐
-	PasswordHash string `gorm:"column:psw_hash"`        // 密码哈希值
+	gorm.Model           // 基本模型
+	UID          uint64  `gorm:"unique;column:uid"`                 // 用户ID
+	UserName     string  `gorm:"unique;column:username"`            // 用户名
+	Salt         string  `gorm:"column:salt"`                       // 盐
+	PasswordHash string  `gorm:"column:psw_hash"`                   // 密码哈希值
+	TOTPSecret   *string `gorm:"column:totp_secret"`                // 两步验证密钥，未注册时为 nil
+	TOTPEnabled  bool    `gorm:"default:false;column:totp_enabled"` // 是否已启用两步验证
+	TOTPLastStep int64   `gorm:"default:0;column:totp_last_step"`   // 最近一次使用的验证码时间步，用于防止重放
 }
 
 // UserLoginLog 用户登录日志模型
@@ -45,13 +50,47 @@ type UserLoginLog struct {
 	Device      string    `gorm:"default:unknown;column:device"`      // 登录时登陆的设备 如：Windows iOS Android
 	Application string    `gorm:"default:unknown;column:application"` // 登录时使用的应用 如 Chrome 236.12
 	BearerToken string    `gorm:"column:bearer_token"`                // 此次登录获取到的令牌
+	SessionID   string    `gorm:"index;column:session_id"`            // 此次登录创建的会话ID
 }
 
-// UserAvaliableToken 用户可用Token模型
-type UserAvaliableToken struct {
+// LoginAttemptCounter 登录尝试计数器模型
+//
+// 每次登录尝试在校验密码或验证码前原子地递增计数，避免并发请求在失败被记录前绕过限制。
+type LoginAttemptCounter struct {
+	CounterKey    string     `gorm:"primaryKey;column:counter_key"` // 计数键，如 uid:1、ip:127.0.0.1
+	WindowStart   time.Time  `gorm:"column:window_start"`           // 当前时间窗口的起始时间
+	Attempts      int64      `gorm:"column:attempts"`               // 时间窗口内失败及进行中的尝试次数
+	LastFailureAt *time.Time `gorm:"column:last_failure_at"`        // 最近一次失败的时间，没有失败记录时为 nil
+}
+
+// UserRefreshToken 用户刷新令牌模型
+//
+// 同一次登录轮换产生的刷新令牌共享同一会话ID，会话内仅有一个未使用的刷新令牌。
+type UserRefreshToken struct {
 	gorm.Model           // 基本模型
-	UID        uint64    `gorm:"column:uid"`          // 用户ID
-	Username   string    `gorm:"column:username"`     // 用户名
-	Token      string    `gorm:"unique;column:token"` // Token
-	ExpireTime time.Time `gorm:"column:expire_time"`  // 过期时间
+	UID        uint64    `gorm:"index;column:uid"`             // 用户ID
+	Username   string    `gorm:"column:username"`              // 用户名
+	SessionID  string    `gorm:"index;column:session_id"`      // 会话ID
+	TokenHash  string    `gorm:"unique;column:token_hash"`     // 刷新令牌哈希值
+	ExpireTime time.Time `gorm:"column:expire_time"`           // 过期时间
+	IsUsed     bool      `gorm:"default:false;column:is_used"` // 是否已被轮换使用
+}
+
+// UserOneTimeToken 用户一次性令牌模型，用于重置密码、验证邮箱等场景
+type UserOneTimeToken struct {
+	gorm.Model            // 基本模型
+	UID        uint64     `gorm:"index;column:uid"`         // 用户ID
+	Purpose    string     `gorm:"index;column:purpose"`     // 用途
+	Target     string     `gorm:"column:target"`            // 令牌绑定的目标，如待验证的邮箱
+	TokenHash  string     `gorm:"unique;column:token_hash"` // 令牌哈希值
+	ExpireTime time.Time  `gorm:"column:expire_time"`       // 过期时间
+	UsedAt     *time.Time `gorm:"column:used_at"`           // 使用时间，未使用时为 nil
+}
+
+// UserRecoveryCode 两步验证恢复码模型
+type UserRecoveryCode struct {
+	gorm.Model            // 基本模型
+	UID        uint64     `gorm:"index;column:uid"` // 用户ID
+	CodeHash   string     `gorm:"column:code_hash"` // 恢复码哈希值
+	UsedAt     *time.Time `gorm:"column:used_at"`   // 使用时间，未使用时为 nil
 }
@@ -0,0 +1,77 @@
+/*
+Package notifiers - NekoBlog backend server notification delivery.
+This file is for log notifier, which is used in local development.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package notifiers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogNotifier 将通知输出到日志及文件的通知发送器，仅用于本地开发
+type LogNotifier struct {
+	logger *logrus.Logger // 日志记录器
+	file   string         // 额外写入的文件路径，为空时不写入文件
+	mutex  sync.Mutex     // 文件写入锁
+}
+
+// NewLogNotifier 创建一个新的日志通知发送器。
+//
+// 参数：
+//   - logger：日志记录器
+//   - file：额外写入的文件路径，为空时不写入文件
+//
+// 返回值：
+//   - *LogNotifier：新的日志通知发送器。
+func NewLogNotifier(logger *logrus.Logger, file string) *LogNotifier {
+	return &LogNotifier{
+		logger: logger,
+		file:   file,
+	}
+}
+
+// Send 将通知消息输出到日志，并追加到文件中。
+//
+// 参数：
+//   - message：通知消息
+//
+// 返回值：
+//   - error：如果在写入文件时发生错误，则返回相应的错误信息，否则返回nil。
+func (notifier *LogNotifier) Send(message *Message) error {
+	notifier.logger.Infof("通知 -> %s [%s]\n%s", message.To, message.Subject, message.Body)
+	if notifier.file == "" {
+		return nil
+	}
+
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+
+	// 创建目录并追加写入
+	err := os.MkdirAll(filepath.Dir(notifier.file), 0o755)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(notifier.file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(
+		file,
+		"[%s] To: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339),
+		message.To,
+		message.Subject,
+		message.Body,
+	)
+	return err
+}
@@ -0,0 +1,55 @@
+/*
+Package notifiers - NekoBlog backend server notification delivery.
+This file is for notifier interface and factory.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package notifiers
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/configs"
+)
+
+// Message 通知消息
+type Message struct {
+	To      string // 收件人地址
+	Subject string // 标题
+	Body    string // 正文
+}
+
+// Notifier 通知发送器
+type Notifier interface {
+	// Send 发送通知消息。
+	//
+	// 参数：
+	//   - message：通知消息
+	//
+	// 返回值：
+	//   - error：如果在发送过程中发生错误，则返回相应的错误信息，否则返回nil。
+	Send(message *Message) error
+}
+
+// NewNotifier 根据配置文件创建通知发送器。
+//
+// 参数：
+//   - cfg：配置文件对象
+//   - logger：日志记录器
+//
+// 返回值：
+//   - Notifier：通知发送器。
+//   - error：如果配置不合法，则返回相应的错误信息，否则返回nil。
+func NewNotifier(cfg *configs.Config, logger *logrus.Logger) (Notifier, error) {
+	switch cfg.Notifier.Type {
+	case "", "log":
+		return NewLogNotifier(logger, cfg.Notifier.File), nil
+	case "smtp":
+		smtpConfig := cfg.Notifier.SMTP
+		return NewSMTPNotifier(smtpConfig.Host, smtpConfig.Port, smtpConfig.Username, smtpConfig.Password, smtpConfig.From)
+	default:
+		return nil, fmt.Errorf("unsupported notifier type: %s", cfg.Notifier.Type)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Package notifiers - NekoBlog backend server notification delivery.
+This file is for SMTP notifier.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package notifiers
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPNotifier 通过 SMTP 发送邮件的通知发送器
+type SMTPNotifier struct {
+	addr string       // SMTP 服务器地址，host:port
+	auth smtp.Auth    // SMTP 认证信息，未配置用户名时为 nil
+	from mail.Address // 发件人
+}
+
+// NewSMTPNotifier 创建一个新的 SMTP 通知发送器。
+//
+// 参数：
+//   - host：SMTP 服务器地址
+//   - port：SMTP 服务器端口
+//   - username：SMTP 用户名，为空时不进行认证
+//   - password：SMTP 密码
+//   - from：发件人地址
+//
+// 返回值：
+//   - *SMTPNotifier：新的 SMTP 通知发送器。
+//   - error：如果配置不合法，则返回相应的错误信息，否则返回nil。
+func NewSMTPNotifier(host string, port int, username string, password string, from string) (*SMTPNotifier, error) {
+	if host == "" || port <= 0 {
+		return nil, errors.New("smtp host and port are required")
+	}
+	fromAddress, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp from address: %w", err)
+	}
+
+	notifier := &SMTPNotifier{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: *fromAddress,
+	}
+	if username != "" {
+		notifier.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return notifier, nil
+}
+
+// Send 发送邮件。
+//
+// 参数：
+//   - message：通知消息
+//
+// 返回值：
+//   - error：如果在发送过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (notifier *SMTPNotifier) Send(message *Message) error {
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return err
+	}
+
+	// 构造邮件
+	var sb strings.Builder
+	sb.WriteString("From: " + notifier.from.String() + "\r\n")
+	sb.WriteString("To: " + to.String() + "\r\n")
+	sb.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", message.Subject) + "\r\n")
+	sb.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	sb.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+
+	return smtp.SendMail(notifier.addr, notifier.auth, notifier.from.Address, []string{to.Address}, []byte(sb.String()))
+}
@@ -1,19 +1,17 @@
 package rontines
 
 import (
-	"errors"
-	"os"
-	"path/filepath"
-
 	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/storages"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 // AvatarCleanerJob 头像清理任务
 type AvatarCleanerJob struct {
-	logger *logrus.Logger // 日志记录器
-	db     *gorm.DB       // 数据库连接
+	logger       *logrus.Logger        // 日志记录器
+	db           *gorm.DB              // 数据库连接
+	mediaStorage storages.MediaStorage // 媒体文件存储
 }
 
 // NewAvatarCleanerJob 创建一个新的头像清理任务。
@@ -21,13 +19,15 @@ type AvatarCleanerJob struct {
 // 参数：
 //   - db：数据库连接
 //   - logger：日志记录器
+//   - mediaStorage：媒体文件存储
 //
 // 返回值：
 //   - *AvatarCleanerJob：新的头像清理任务。
-func NewAvatarCleanerJob(logger *logrus.Logger, db *gorm.DB) *AvatarCleanerJob {
+func NewAvatarCleanerJob(logger *logrus.Logger, db *gorm.DB, mediaStorage storages.MediaStorage) *AvatarCleanerJob {
 	return &AvatarCleanerJob{
-		logger: logger,
-		db:     db,
+		logger:       logger,
+		db:           db,
+		mediaStorage: mediaStorage,
 	}
 }
 
@@ -44,17 +44,8 @@ func (job *AvatarCleanerJob) Run() {
 
 	// 清理头像
 	for _, item := range waitList {
-		err := os.Remove(filepath.Join("./public/avatars", item.FileName))
-		// 头像文件不存在
-		if errors.Is(err, os.ErrNotExist) {
-			job.logger.Errorln("wtf")
-			job.logger.Warnln("头像文件不存在:", item.FileName)
-			result = job.db.Unscoped().Delete(&item)
-			if result.Error != nil {
-				job.logger.Errorln("清理头像失败:", result.Error)
-			}
-			continue
-		}
+		// 头像文件不存在时同样视为清理成功
+		err := job.mediaStorage.Delete(storages.AvatarKey(item.FileName))
 		if err != nil {
 			job.logger.Warningln("清理头像失败:", err)
 			continue
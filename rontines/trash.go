@@ -0,0 +1,140 @@
+package rontines
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/storages"
+)
+
+// TrashPurgeJob 回收站清理任务
+type TrashPurgeJob struct {
+	logger       *logrus.Logger        // 日志记录器
+	db           *gorm.DB              // 数据库连接
+	mediaStorage storages.MediaStorage // 媒体文件存储
+	retention    time.Duration         // 回收站保留时长
+}
+
+// NewTrashPurgeJob 创建一个新的回收站清理任务。
+//
+// 参数：
+//   - logger：日志记录器
+//   - db：数据库连接
+//   - mediaStorage：媒体文件存储
+//   - retention：已删除内容在回收站中的保留时长
+//
+// 返回值：
+//   - *TrashPurgeJob：新的回收站清理任务。
+func NewTrashPurgeJob(logger *logrus.Logger, db *gorm.DB, mediaStorage storages.MediaStorage, retention time.Duration) *TrashPurgeJob {
+	return &TrashPurgeJob{
+		logger:       logger,
+		db:           db,
+		mediaStorage: mediaStorage,
+		retention:    retention,
+	}
+}
+
+// Run 执行回收站清理任务。
+func (job *TrashPurgeJob) Run() {
+	job.logger.Infoln("正在执行回收站清理任务...")
+	cutoff := time.Now().Add(-job.retention)
+
+	// 获取超过保留期的博文
+	var posts []models.PostInfo
+	result := job.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Find(&posts)
+	if result.Error != nil {
+		job.logger.Errorln("获取待清理博文失败:", result.Error)
+		return
+	}
+
+	// 清理博文
+	for _, post := range posts {
+		images, err := job.purgePost(post)
+		if err != nil {
+			job.logger.Errorln("清理博文失败:", post.ID, err)
+			continue
+		}
+		job.removeOrphanImages(images)
+	}
+
+	// 清理超过保留期的评论，同时删除的回复删除时间相同，会被一并清理
+	result = job.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(&models.CommentInfo{})
+	if result.Error != nil {
+		job.logger.Errorln("清理评论失败:", result.Error)
+		return
+	}
+
+	job.logger.Infoln("回收站清理任务执行完毕")
+}
+
+// purgePost 彻底删除博文及其评论、修订记录与时间线条目。
+//
+// 参数：
+//   - post：待清理的博文
+//
+// 返回值：
+//   - []string：博文及其修订记录引用过的图片文件名。
+//   - error：如果在清理过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (job *TrashPurgeJob) purgePost(post models.PostInfo) ([]string, error) {
+	images := append([]string(nil), post.Images...)
+	err := job.db.Transaction(func(tx *gorm.DB) error {
+		// 收集修订记录引用的图片
+		var revisionImages []pq.StringArray
+		result := tx.Unscoped().Model(&models.PostRevision{}).Where("post_id = ?", post.ID).Pluck("images", &revisionImages)
+		if result.Error != nil {
+			return result.Error
+		}
+		for _, revisionImage := range revisionImages {
+			images = append(images, revisionImage...)
+		}
+
+		// 删除评论、修订记录与时间线条目
+		if result = tx.Unscoped().Where("post_id = ?", post.ID).Delete(&models.CommentInfo{}); result.Error != nil {
+			return result.Error
+		}
+		if result = tx.Unscoped().Where("post_id = ?", post.ID).Delete(&models.PostRevision{}); result.Error != nil {
+			return result.Error
+		}
+		if result = tx.Where("post_id = ?", post.ID).Delete(&models.TimelineEntry{}); result.Error != nil {
+			return result.Error
+		}
+
+		// 删除博文
+		return tx.Unscoped().Where("id = ?", post.ID).Delete(&models.PostInfo{}).Error
+	})
+	return images, err
+}
+
+// removeOrphanImages 删除不再被任何博文或修订记录引用的图片文件。
+//
+// 参数：
+//   - images：待检查的图片文件名
+func (job *TrashPurgeJob) removeOrphanImages(images []string) {
+	for _, image := range images {
+		// 检查图片是否仍被引用
+		var postCount, revisionCount int64
+		result := job.db.Unscoped().Model(&models.PostInfo{}).Where("? = ANY(images)", image).Count(&postCount)
+		if result.Error != nil {
+			job.logger.Errorln("检查图片引用失败:", image, result.Error)
+			continue
+		}
+		result = job.db.Unscoped().Model(&models.PostRevision{}).Where("? = ANY(images)", image).Count(&revisionCount)
+		if result.Error != nil {
+			job.logger.Errorln("检查图片引用失败:", image, result.Error)
+			continue
+		}
+		if postCount > 0 || revisionCount > 0 {
+			continue
+		}
+
+		// 删除图片文件
+		err := job.mediaStorage.Delete(storages.PostImageKey(image))
+		if err != nil {
+			job.logger.Warningln("清理博文图片失败:", err)
+		}
+	}
+}
@@ -10,13 +10,27 @@ package services
 import (
 	"errors"
 
+	"gorm.io/gorm"
+
 	"github.com/Kirisakiii/neko-micro-blog-backend/models"
 	"github.com/Kirisakiii/neko-micro-blog-backend/stores"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/authorizers"
+)
+
+var (
+	// ErrCommentPostDeleted 评论所属的博文已被删除
+	ErrCommentPostDeleted = errors.New("the post of the comment has been deleted")
+
+	// ErrCommentParentDeleted 所回复的评论已被删除
+	ErrCommentParentDeleted = errors.New("parent comment has been deleted")
 )
 
 // CommentService 评论服务
 type CommentService struct {
 	commentStore *stores.CommentStore
+	postStore    *stores.PostStore
+	userStore    *stores.UserStore
 }
 
 // NewCommentService 返回一个新的评论服务实例。
@@ -26,28 +40,48 @@ type CommentService struct {
 func (factory *Factory) NewCommentService() *CommentService {
 	return &CommentService{
 		commentStore: factory.storeFactory.NewCommentStore(),
+		postStore:    factory.storeFactory.NewPostStore(),
+		userStore:    factory.storeFactory.NewUserStore(),
 	}
 }
 
-// NewCommentService 创建评论
+// CreateComment 创建评论
 //
 // 参数：
 //   - uid：用户ID
 //   - postID: 博文编号
+//   - parentID: 回复的评论ID，不是回复时为nil
 //   - content: 博文内容
+//   - isPublic: 是否公开
 //   - postStore，userStore：绑定post和user层来调用方法
 //
 // 返回值：
 //
 //	-error 创建失败返回创建失败时候的具体信息
-func (service *CommentService) CreateComment(uid uint64, postID uint64, content string, postStore *stores.PostStore, userStore *stores.UserStore) error {
-	// 校验评论是否存在
-	existance, err := postStore.ValidatePostExistence(postID)
+func (service *CommentService) CreateComment(uid uint64, postID uint64, parentID *uint64, content string, isPublic bool, postStore *stores.PostStore, userStore *stores.UserStore) error {
+	// 校验博文是否存在且可见
+	_, err := postStore.GetVisiblePostInfo(postID, uid)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("post does not exist")
+	}
 	if err != nil {
 		return err
 	}
-	if !existance {
-		return errors.New("post does not exist")
+
+	// 校验回复的评论是否属于同一博文
+	var parent *models.CommentInfo
+	if parentID != nil {
+		comment, err := service.commentStore.GetVisibleCommentInfo(*parentID, uid)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("parent comment does not exist")
+		}
+		if err != nil {
+			return err
+		}
+		if comment.PostID != postID {
+			return errors.New("parent comment does not belong to the post")
+		}
+		parent = &comment
 	}
 
 	// 根据 UID 获取 Username
@@ -57,7 +91,7 @@ func (service *CommentService) CreateComment(uid uint64, postID uint64, content
 	}
 
 	// 调用存储层的方法存储评论
-	err = service.commentStore.CreateComment(uid, user.UserName, postID, content)
+	err = service.commentStore.CreateComment(uid, user.UserName, postID, parent, content, isPublic)
 	if err != nil {
 		return err
 	}
@@ -67,22 +101,19 @@ func (service *CommentService) CreateComment(uid uint64, postID uint64, content
 // UpdateComment 修改评论
 //
 // 参数：
+//   - uid：执行修改操作的用户ID
 //   - comment：评论ID
 //   - content: 博文内容
 //
 // 返回值：
 //
 //	-error 如果评论存在返回修改评论时候的信息
-func (service *CommentService) UpdateComment(commentID uint64, content string) error {
-	//检查评论是否存在
-	// TODO: 改为控制器层判断
-	exists, err := service.commentStore.ValidateCommentExistence(commentID)
+func (service *CommentService) UpdateComment(uid uint64, commentID uint64, content string) error {
+	// 校验评论是否存在及操作权限
+	err := service.authorizeCommentAction(uid, commentID, types.RESOURCE_ACTION_UPDATE)
 	if err != nil {
 		return err
 	}
-	if !exists {
-		return errors.New("comment does not exist")
-	}
 
 	// 调用数据库或其他存储方法更新评论内容
 	err = service.commentStore.UpdateComment(commentID, content)
@@ -94,24 +125,23 @@ func (service *CommentService) UpdateComment(commentID uint64, content string) e
 	return nil
 }
 
-// DeleteComment 删除评论
+// DeleteComment 删除评论，评论下的回复一并删除
 //
 // 参数：
+//   - uid：执行删除操作的用户ID
 //   - commentID: 评论ID
 //
 // 返回值：
 //   - error 返回处理删除的信息
-func (service *CommentService) DeleteComment(commentID uint64) error {
-	// TODO: 改为控制器层判断
-	exists, err := service.commentStore.ValidateCommentExistence(commentID)
+func (service *CommentService) DeleteComment(uid uint64, commentID uint64) error {
+	// 校验评论是否存在及操作权限
+	err := service.authorizeCommentAction(uid, commentID, types.RESOURCE_ACTION_DELETE)
 	if err != nil {
 		return err
 	}
-	if !exists {
-		return errors.New("comment does not exist")
-	}
-	// 调用评论存储中的删除评论方法
-	err = service.commentStore.DeleteComment(commentID)
+
+	// 将评论移入回收站
+	err = service.commentStore.DeleteComment(commentID, uid)
 	if err != nil {
 		// 如果发生错误，则返回错误
 		return err
@@ -121,20 +151,204 @@ func (service *CommentService) DeleteComment(commentID uint64) error {
 	return nil
 }
 
-// GetCommentList 获取评论列表
+// GetTrashedCommentList 按发布时间倒序分页获取用户回收站中的评论，仅包含用户自行删除的评论。
+//
+// 参数：
+//   - uid：用户ID
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - []models.CommentInfo：当前页的评论信息。
+//   - *types.Cursor：下一页的游标，没有下一页时为 nil。
+//   - error：如果在获取过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *CommentService) GetTrashedCommentList(uid uint64, cursor *types.Cursor, limit int) ([]models.CommentInfo, *types.Cursor, error) {
+	comments, err := service.commentStore.GetTrashedCommentList(uid, cursor, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	comments, nextCursor := cutPage(comments, limit, func(comment models.CommentInfo) *types.Cursor {
+		return modelCursor(comment.Model)
+	})
+	return comments, nextCursor, nil
+}
+
+// RestoreComment 从回收站中恢复评论。
+//
+// 执行删除的用户可以恢复评论，其他用户需要达到删除他人评论所需的权限等级。
+// 与评论同时删除的回复一并恢复，所回复的评论仍在回收站中时不能单独恢复。
+//
+// 参数：
+//   - uid：执行恢复操作的用户ID
+//   - commentID：评论ID
+//
+// 返回值：
+//   - error：评论不在回收站中时返回 gorm.ErrRecordNotFound，所属博文已被删除时返回 ErrCommentPostDeleted，
+//     所回复的评论已删除时返回 ErrCommentParentDeleted，无权恢复时返回 authorizers.ErrPermissionDenied，
+//     其他错误返回相应的错误信息，否则返回nil。
+func (service *CommentService) RestoreComment(uid uint64, commentID uint64) error {
+	comment, err := service.commentStore.GetDeletedCommentInfo(commentID)
+	if err != nil {
+		return err
+	}
+
+	// 所属博文已被删除时需要先恢复博文
+	exists, err := service.postStore.ValidatePostExistence(comment.PostID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrCommentPostDeleted
+	}
+
+	// 校验操作权限
+	operator, err := service.userStore.GetUserByUID(uid)
+	if err != nil {
+		return err
+	}
+	deletedBy := comment.UID
+	if comment.DeletedBy != nil {
+		deletedBy = *comment.DeletedBy
+	}
+	err = authorizers.AuthorizeResourceAction(operator, deletedBy, types.RESOURCE_ACTION_DELETE)
+	if err != nil {
+		return err
+	}
+
+	// 所回复的评论需先恢复
+	if comment.ParentID != nil {
+		_, err = service.commentStore.GetCommentInfo(*comment.ParentID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCommentParentDeleted
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return service.commentStore.RestoreComment(commentID)
+}
+
+// authorizeCommentAction 校验用户是否有权对评论执行指定操作。
+//
+// 参数：
+//   - uid：执行操作的用户ID
+//   - commentID：评论ID
+//   - action：操作类型
 //
 // 返回值：
-//   - 成功则返回评论列表
+//   - error：如果评论不存在或无权操作，则返回相应的错误信息，否则返回nil。
+func (service *CommentService) authorizeCommentAction(uid uint64, commentID uint64, action types.ResourceAction) error {
+	comment, err := service.commentStore.GetCommentInfo(commentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("comment does not exist")
+	}
+	if err != nil {
+		return err
+	}
+
+	operator, err := service.userStore.GetUserByUID(uid)
+	if err != nil {
+		return err
+	}
+
+	return authorizers.AuthorizeResourceAction(operator, comment.UID, action)
+}
+
+// GetCommentList 按发布时间倒序分页获取博文下访问者可见的评论列表
+//
+// 参数：
+//   - postID：博文ID
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - 成功则返回当前页的评论列表及下一页的游标
 //   - 失败返回nil
-func (service *CommentService) GetCommentList() ([]models.CommentInfo, error) {
-	return service.commentStore.GetCommentList()
+func (service *CommentService) GetCommentList(postID uint64, viewerUID uint64, cursor *types.Cursor, limit int) ([]models.CommentInfo, *types.Cursor, error) {
+	comments, err := service.commentStore.GetCommentList(postID, viewerUID, cursor, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	comments, nextCursor := cutPage(comments, limit, func(comment models.CommentInfo) *types.Cursor {
+		return modelCursor(comment.Model)
+	})
+	return comments, nextCursor, nil
+}
+
+// GetReplyList 获取评论下访问者可见的回复列表
+//
+// 参数：
+//   - commentID：评论ID
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - 成功则返回当前页的回复列表及下一页的游标
+//   - 评论不存在或对访问者不可见时返回 gorm.ErrRecordNotFound
+func (service *CommentService) GetReplyList(commentID uint64, viewerUID uint64, cursor *types.Cursor, limit int) ([]models.CommentInfo, *types.Cursor, error) {
+	// 被回复的评论已删除或不可见时不再列出回复
+	if _, err := service.commentStore.GetVisibleCommentInfo(commentID, viewerUID); err != nil {
+		return nil, nil, err
+	}
+
+	replies, err := service.commentStore.GetReplyList(commentID, viewerUID, cursor, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	replies, nextCursor := cutPage(replies, limit, func(comment models.CommentInfo) *types.Cursor {
+		return modelCursor(comment.Model)
+	})
+	return replies, nextCursor, nil
 }
 
-// GetCommentInfo 获取评论信息
+// GetCommentInfo 获取访问者可见的评论信息
+//
+// 参数：
+//   - commentID：评论ID
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
 //
 // 返回值：
 //   - 成功返回评论体
-//   - 失败返回nil
-func (service *CommentService) GetCommentInfo(commentID uint64) (models.CommentInfo, error) {
-	return service.commentStore.GetCommentInfo(commentID)
+//   - 评论不存在或不可见时返回 gorm.ErrRecordNotFound
+func (service *CommentService) GetCommentInfo(commentID uint64, viewerUID uint64) (models.CommentInfo, error) {
+	return service.commentStore.GetVisibleCommentInfo(commentID, viewerUID)
+}
+
+// LikeComment 点赞评论
+//
+// 参数：
+//   - uid：用户ID
+//   - commentID：评论ID
+//
+// 返回值：
+//   - error 返回处理点赞的信息
+func (service *CommentService) LikeComment(uid uint64, commentID uint64) error {
+	return service.commentStore.LikeComment(commentID, uid)
+}
+
+// DislikeComment 点踩评论
+//
+// 参数：
+//   - uid：用户ID
+//   - commentID：评论ID
+//
+// 返回值：
+//   - error 返回处理点踩的信息
+func (service *CommentService) DislikeComment(uid uint64, commentID uint64) error {
+	return service.commentStore.DislikeComment(commentID, uid)
+}
+
+// ClearCommentReaction 清除对评论的点赞或点踩
+//
+// 参数：
+//   - uid：用户ID
+//   - commentID：评论ID
+//
+// 返回值：
+//   - error 返回处理清除的信息
+func (service *CommentService) ClearCommentReaction(uid uint64, commentID uint64) error {
+	return service.commentStore.ClearCommentReaction(commentID, uid)
 }
@@ -6,20 +6,44 @@ Copyright (c) [2024], Author(s):
 */
 package services
 
-import "github.com/Kirisakiii/neko-micro-blog-backend/stores"
+import (
+	"github.com/Kirisakiii/neko-micro-blog-backend/notifiers"
+	"github.com/Kirisakiii/neko-micro-blog-backend/stores"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+)
 
 // Factory 服务工厂
 type Factory struct {
-	storeFactory *stores.Factory
+	storeFactory       *stores.Factory
+	tokenSettings      *types.TokenSettings
+	passwordHashParams *types.PasswordHashParams
+	timelineStrategy   string
+	notifier           notifiers.Notifier
 }
 
 // NewFactory 创建服务工厂
 //
 // 参数：
 // storeFactory *stores.Factory - 存储工厂
+// tokenSettings *types.TokenSettings - 令牌设置
+// passwordHashParams *types.PasswordHashParams - 密码哈希参数
+// timelineStrategy string - 时间线生成策略
+// notifier notifiers.Notifier - 通知发送器
 //
 // 返回值：
 // *Factory - 服务工厂
-func NewFactory(storeFactory *stores.Factory) *Factory {
-	return &Factory{storeFactory: storeFactory}
+func NewFactory(
+	storeFactory *stores.Factory,
+	tokenSettings *types.TokenSettings,
+	passwordHashParams *types.PasswordHashParams,
+	timelineStrategy string,
+	notifier notifiers.Notifier,
+) *Factory {
+	return &Factory{
+		storeFactory:       storeFactory,
+		tokenSettings:      tokenSettings,
+		passwordHashParams: passwordHashParams,
+		timelineStrategy:   timelineStrategy,
+		notifier:           notifier,
+	}
 }
@@ -0,0 +1,47 @@
+/*
+Package services - NekoBlog backend server services.
+This file is for pagination helpers.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package services
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+)
+
+// cutPage 截取一页数据并计算下一页的游标。
+//
+// 存储层会多查询一条记录，若结果条目数超过 limit 则说明存在下一页。
+//
+// 参数：
+//   - items：存储层查询结果
+//   - limit：每页条目数
+//   - cursorOf：根据记录生成游标的函数
+//
+// 返回值：
+//   - []T：当前页的数据。
+//   - *types.Cursor：下一页的游标，没有下一页时为 nil。
+func cutPage[T any](items []T, limit int, cursorOf func(T) *types.Cursor) ([]T, *types.Cursor) {
+	if len(items) <= limit {
+		return items, nil
+	}
+	items = items[:limit]
+	return items, cursorOf(items[limit-1])
+}
+
+// modelCursor 根据基本模型生成游标。
+//
+// 参数：
+//   - model：基本模型
+//
+// 返回值：
+//   - *types.Cursor：指向该记录的游标。
+func modelCursor(model gorm.Model) *types.Cursor {
+	return &types.Cursor{
+		CreatedAt: model.CreatedAt,
+		ID:        uint64(model.ID),
+	}
+}
@@ -10,19 +10,38 @@ Copyright (c) [2024], Author(s):
 package services
 
 import (
+	"errors"
 	"mime/multipart"
+	"slices"
+
+	"gorm.io/gorm"
 
 	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
 	"github.com/Kirisakiii/neko-micro-blog-backend/models"
 	"github.com/Kirisakiii/neko-micro-blog-backend/stores"
 	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/authorizers"
 	"github.com/Kirisakiii/neko-micro-blog-backend/utils/converters"
 	"github.com/Kirisakiii/neko-micro-blog-backend/utils/validers"
 )
 
+var (
+	// ErrUserPostTabInvalid 用户主页博文标签页不存在
+	ErrUserPostTabInvalid = errors.New("invalid tab")
+
+	// ErrFavoritesPrivate 收藏列表仅本人可见
+	ErrFavoritesPrivate = errors.New("favorites are only visible to the owner")
+
+	// ErrTooManyPostImages 博文图片数量超过上限
+	ErrTooManyPostImages = errors.New("the number of images cannot exceed 9")
+)
+
 // PostService 博文服务
 type PostService struct {
-	postStore *stores.PostStore
+	postStore        *stores.PostStore
+	userStore        *stores.UserStore
+	timelineStore    *stores.TimelineStore
+	timelineStrategy string
 }
 
 // PostService 返回一个新的 PostService 实例
@@ -31,31 +50,117 @@ type PostService struct {
 //   - *PostService：新的 PostService 实力。
 func (factory *Factory) NewPostService() *PostService {
 	return &PostService{
-		postStore: factory.storeFactory.NewPostStore(),
+		postStore:        factory.storeFactory.NewPostStore(),
+		userStore:        factory.storeFactory.NewUserStore(),
+		timelineStore:    factory.storeFactory.NewTimelineStore(),
+		timelineStrategy: factory.timelineStrategy,
 	}
 }
 
-// GetPostList 获取适用于用户查看的帖子信息列表。
+// GetPostList 按发布时间倒序分页获取访问者可见的博文列表。
+//
+// 参数：
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
 // 返回值：
-// - []models.UserPostInfo: 包含适用于用户查看的帖子信息的切片。
+// - []models.PostInfo: 当前页的博文信息。
+// - *types.Cursor: 下一页的游标，没有下一页时为 nil。
 // - error: 在获取帖子信息过程中遇到的任何错误，如果有的话。
-func (service *PostService) GetPostList() ([]models.PostInfo, error) {
-	userPosts, err := service.postStore.GetPostList()
+func (service *PostService) GetPostList(viewerUID uint64, cursor *types.Cursor, limit int) ([]models.PostInfo, *types.Cursor, error) {
+	userPosts, err := service.postStore.GetPostList(viewerUID, cursor, limit)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return userPosts, nil
+	userPosts, nextCursor := cutPage(userPosts, limit, func(post models.PostInfo) *types.Cursor {
+		return modelCursor(post.Model)
+	})
+	return userPosts, nextCursor, nil
 }
 
-// GetPostInfoByUsername 根据用户名获取用户信息。
+// GetUserPostList 按发布时间倒序分页获取用户主页指定标签页下访问者可见的博文列表。
 //
 // 参数：
-//   - UID：用户ID
+//   - uid：用户ID
+//   - tab：标签页，取值见 consts.USER_POST_TAB_*
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
 //
 // 返回值：
-//   - *models.postInfo：用户信息模型。
-func (service *PostService) GetPostInfo(postID uint64) (models.PostInfo, error) {
-	post, err := service.postStore.GetPostInfo(postID)
+//   - []models.PostInfo：当前页的博文信息。
+//   - *types.Cursor：下一页的游标，没有下一页时为 nil。
+//   - error：用户不存在时返回 gorm.ErrRecordNotFound，标签页不存在时返回 ErrUserPostTabInvalid，
+//     非本人查看收藏时返回 ErrFavoritesPrivate，其他错误返回相应的错误信息，否则返回nil。
+func (service *PostService) GetUserPostList(uid uint64, tab string, viewerUID uint64, cursor *types.Cursor, limit int) ([]models.PostInfo, *types.Cursor, error) {
+	switch tab {
+	case consts.USER_POST_TAB_POSTS, consts.USER_POST_TAB_IMAGES, consts.USER_POST_TAB_LIKES:
+	case consts.USER_POST_TAB_FAVORITES:
+		if viewerUID != uid {
+			return nil, nil, ErrFavoritesPrivate
+		}
+	default:
+		return nil, nil, ErrUserPostTabInvalid
+	}
+
+	if _, err := service.userStore.GetUserByUID(uid); err != nil {
+		return nil, nil, err
+	}
+
+	posts, err := service.postStore.GetUserPostList(uid, tab, viewerUID, cursor, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	posts, nextCursor := cutPage(posts, limit, func(post models.PostInfo) *types.Cursor {
+		return modelCursor(post.Model)
+	})
+	return posts, nextCursor, nil
+}
+
+// GetTimeline 按发布时间倒序分页获取用户的首页时间线，包含用户本人及其关注用户的博文。
+//
+// 参数：
+//   - uid：用户ID
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - []models.PostInfo：当前页的博文信息。
+//   - *types.Cursor：下一页的游标，没有下一页时为 nil。
+//   - error：如果在获取过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *PostService) GetTimeline(uid uint64, cursor *types.Cursor, limit int) ([]models.PostInfo, *types.Cursor, error) {
+	var (
+		posts []models.PostInfo
+		err   error
+	)
+	switch service.timelineStrategy {
+	case consts.TIMELINE_STRATEGY_WRITE:
+		posts, err = service.timelineStore.GetTimelineByEntries(uid, cursor, limit)
+	default:
+		posts, err = service.timelineStore.GetTimelineByFollows(uid, cursor, limit)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	posts, nextCursor := cutPage(posts, limit, func(post models.PostInfo) *types.Cursor {
+		return modelCursor(post.Model)
+	})
+	return posts, nextCursor, nil
+}
+
+// GetPostInfo 获取访问者可见的博文信息。
+//
+// 参数：
+//   - postID：博文ID
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//
+// 返回值：
+//   - models.PostInfo：博文信息模型。
+//   - error：博文不存在或不可见时返回 gorm.ErrRecordNotFound。
+func (service *PostService) GetPostInfo(postID uint64, viewerUID uint64) (models.PostInfo, error) {
+	post, err := service.postStore.GetVisiblePostInfo(postID, viewerUID)
 	if err != nil {
 		return models.PostInfo{}, err
 	}
@@ -73,17 +178,358 @@ func (service *PostService) GetPostInfo(postID uint64) (models.PostInfo, error)
 // 返回值：
 //   - error：如果在创建过程中发生错误，则返回相应的错误信息，否则返回nil。
 func (service *PostService) CreatePost(uid uint64, ipAddr string, postReqInfo types.PostCreateBody, postImages []*multipart.FileHeader) (models.PostInfo, error) {
-	var (
-		converteredImages [][]byte
-		imageFile         multipart.File
-		err               error
-	)
 	// 校验并处理图片
+	converteredImages, err := processPostImages(postImages)
+	if err != nil {
+		return models.PostInfo{}, err
+	}
+
+	// 调用存储层的方法创建帖子
+	postInfo, err := service.postStore.CreatePost(uid, ipAddr, postReqInfo, converteredImages)
+	if err != nil {
+		return models.PostInfo{}, err
+	}
+
+	// 推送到时间线
+	if err = service.fanOutPost(postInfo); err != nil {
+		return models.PostInfo{}, err
+	}
+	return postInfo, nil
+}
+
+// EditPost 编辑博文，仅博文作者本人可以编辑。
+//
+// 参数：
+//   - uid：执行编辑操作的用户ID
+//   - postID：博文ID
+//   - reqBody：编辑请求体，未提供的字段保持不变
+//   - postImages：新上传的图片，追加在保留的图片之后
+//
+// 返回值：
+//   - models.PostInfo：编辑后的博文。
+//   - error：博文不存在时返回 gorm.ErrRecordNotFound，非作者本人时返回 authorizers.ErrPermissionDenied，
+//     图片过多时返回 ErrTooManyPostImages，其他错误返回相应的错误信息，否则返回nil。
+func (service *PostService) EditPost(uid uint64, postID uint64, reqBody types.PostEditBody, postImages []*multipart.FileHeader) (models.PostInfo, error) {
+	// 获取博文信息
+	post, err := service.postStore.GetPostInfo(postID)
+	if err != nil {
+		return models.PostInfo{}, err
+	}
+
+	// 仅作者本人可以编辑
+	if post.UID != uid {
+		return models.PostInfo{}, authorizers.ErrPermissionDenied
+	}
+
+	// 计算保留的图片
+	keptImages := make([]string, 0, len(post.Images))
+	for _, image := range post.Images {
+		if !slices.Contains(reqBody.RemoveImages, image) {
+			keptImages = append(keptImages, image)
+		}
+	}
+	if len(keptImages)+len(postImages) > consts.POST_MAX_IMAGE_COUNT {
+		return models.PostInfo{}, ErrTooManyPostImages
+	}
+
+	// 校验并处理新图片
+	converteredImages, err := processPostImages(postImages)
+	if err != nil {
+		return models.PostInfo{}, err
+	}
+
+	return service.postStore.EditPost(postID, uid, reqBody, keptImages, converteredImages)
+}
+
+// GetPostRevisions 按修订时间倒序分页获取博文的修订记录。
+//
+// 修订记录可能包含作者已删改的内容，仅作者与版主及以上权限的用户可以查看。
+//
+// 参数：
+//   - uid：访问者的用户ID
+//   - postID：博文ID
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - []models.PostRevision：当前页的修订记录。
+//   - *types.Cursor：下一页的游标，没有下一页时为 nil。
+//   - error：博文不存在时返回 gorm.ErrRecordNotFound，无权查看时返回 authorizers.ErrPermissionDenied，其他错误返回相应的错误信息，否则返回nil。
+func (service *PostService) GetPostRevisions(uid uint64, postID uint64, cursor *types.Cursor, limit int) ([]models.PostRevision, *types.Cursor, error) {
+	// 获取博文信息
+	post, err := service.postStore.GetPostInfo(postID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 校验操作权限
+	operator, err := service.userStore.GetUserByUID(uid)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = authorizers.AuthorizeResourceAction(operator, post.UID, types.RESOURCE_ACTION_VIEW_HISTORY)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	revisions, err := service.postStore.GetPostRevisions(postID, cursor, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	revisions, nextCursor := cutPage(revisions, limit, func(revision models.PostRevision) *types.Cursor {
+		return modelCursor(revision.Model)
+	})
+	return revisions, nextCursor, nil
+}
+
+// ForwardPost 转发博文。
+//
+// 参数：
+//   - uid：转发者的用户ID
+//   - ipAddr：IP地址
+//   - reqBody：转发请求体
+//
+// 返回值：
+//   - models.PostInfo：新创建的博文。
+//   - error：如果在转发过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *PostService) ForwardPost(uid uint64, ipAddr string, reqBody types.PostForwardBody) (models.PostInfo, error) {
+	postInfo, err := service.postStore.ForwardPost(uid, ipAddr, *reqBody.ParentPostID, reqBody)
+	if err != nil {
+		return models.PostInfo{}, err
+	}
+
+	// 推送到时间线
+	if err = service.fanOutPost(postInfo); err != nil {
+		return models.PostInfo{}, err
+	}
+	return postInfo, nil
+}
+
+// fanOutPost 在写扩散模式下将新博文推送到作者本人及其关注者的时间线，读扩散模式下不做任何操作。
+//
+// 参数：
+//   - post：新博文
+//
+// 返回值：
+//   - error：如果在推送过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *PostService) fanOutPost(post models.PostInfo) error {
+	if service.timelineStrategy != consts.TIMELINE_STRATEGY_WRITE {
+		return nil
+	}
+	return service.timelineStore.FanOutPost(post)
+}
+
+// GetParentPostInfo 获取转发博文的原博文。
+//
+// 参数：
+//   - post：转发博文
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//
+// 返回值：
+//   - *models.PostInfo：原博文，博文不是转发或原博文已被删除、不可见时为 nil。
+//   - bool：原博文是否已被删除，原博文存在但对访问者不可见时为 false。
+//   - error：如果在获取过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *PostService) GetParentPostInfo(post models.PostInfo, viewerUID uint64) (*models.PostInfo, bool, error) {
+	if post.ParentPostID == nil {
+		return nil, false, nil
+	}
+
+	parent, err := service.postStore.GetVisiblePostInfo(*post.ParentPostID, viewerUID)
+	if err == nil {
+		return &parent, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	// 区分原博文已被删除与对访问者不可见
+	_, err = service.postStore.GetPostInfo(*post.ParentPostID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return nil, false, nil
+}
+
+// DeletePost 是用于删除博文的服务方法
+//
+// 参数：
+// - uid uint64：执行删除操作的用户ID
+// - postID uint64：待删除博文的ID
+//
+// 返回值：
+// - error：如果发生错误，返回相应错误信息；否则返回 nil
+func (service *PostService) DeletePost(uid uint64, postID uint64) error {
+	// 获取博文信息
+	post, err := service.postStore.GetPostInfo(postID)
+	if err != nil {
+		return err
+	}
+
+	// 校验操作权限
+	operator, err := service.userStore.GetUserByUID(uid)
+	if err != nil {
+		return err
+	}
+	err = authorizers.AuthorizeResourceAction(operator, post.UID, types.RESOURCE_ACTION_DELETE)
+	if err != nil {
+		return err
+	}
+
+	// 将博文移入回收站
+	return service.postStore.DeletePost(postID, uid)
+}
+
+// GetTrashedPostList 按发布时间倒序分页获取用户回收站中的博文，仅包含用户自行删除的博文。
+//
+// 参数：
+//   - uid：用户ID
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - []models.PostInfo：当前页的博文信息。
+//   - *types.Cursor：下一页的游标，没有下一页时为 nil。
+//   - error：如果在获取过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *PostService) GetTrashedPostList(uid uint64, cursor *types.Cursor, limit int) ([]models.PostInfo, *types.Cursor, error) {
+	posts, err := service.postStore.GetTrashedPostList(uid, cursor, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	posts, nextCursor := cutPage(posts, limit, func(post models.PostInfo) *types.Cursor {
+		return modelCursor(post.Model)
+	})
+	return posts, nextCursor, nil
+}
+
+// RestorePost 从回收站中恢复博文。
+//
+// 执行删除的用户可以恢复博文，其他用户需要达到删除他人博文所需的权限等级，
+// 因此被管理员删除的博文不能由作者自行恢复。
+//
+// 参数：
+//   - uid：执行恢复操作的用户ID
+//   - postID：博文ID
+//
+// 返回值：
+//   - error：博文不在回收站中时返回 gorm.ErrRecordNotFound，无权恢复时返回 authorizers.ErrPermissionDenied，
+//     其他错误返回相应的错误信息，否则返回nil。
+func (service *PostService) RestorePost(uid uint64, postID uint64) error {
+	post, err := service.postStore.GetDeletedPostInfo(postID)
+	if err != nil {
+		return err
+	}
+
+	// 校验操作权限
+	operator, err := service.userStore.GetUserByUID(uid)
+	if err != nil {
+		return err
+	}
+	deletedBy := post.UID
+	if post.DeletedBy != nil {
+		deletedBy = *post.DeletedBy
+	}
+	err = authorizers.AuthorizeResourceAction(operator, deletedBy, types.RESOURCE_ACTION_DELETE)
+	if err != nil {
+		return err
+	}
+
+	return service.postStore.RestorePost(postID)
+}
+
+// UpdatePostVisibility 修改博文的可见性。
+//
+// 参数：
+//   - uid：执行修改操作的用户ID
+//   - postID：博文ID
+//   - visibility：可见性
+//
+// 返回值：
+//   - error：如果发生错误，返回相应错误信息；否则返回 nil
+func (service *PostService) UpdatePostVisibility(uid uint64, postID uint64, visibility string) error {
+	// 获取博文信息
+	post, err := service.postStore.GetPostInfo(postID)
+	if err != nil {
+		return err
+	}
+
+	// 校验操作权限
+	operator, err := service.userStore.GetUserByUID(uid)
+	if err != nil {
+		return err
+	}
+	err = authorizers.AuthorizeResourceAction(operator, post.UID, types.RESOURCE_ACTION_UPDATE)
+	if err != nil {
+		return err
+	}
+
+	return service.postStore.UpdatePostVisibility(postID, visibility)
+}
+
+// LikePost 点赞博文。
+//
+// 参数：
+//   - uid：用户ID
+//   - postID：博文ID
+//
+// 返回值：
+//   - error：如果发生错误，返回相应错误信息；否则返回 nil
+func (service *PostService) LikePost(uid uint64, postID uint64) error {
+	return service.postStore.LikePost(postID, uid)
+}
+
+// UnlikePost 取消点赞博文。
+//
+// 参数：
+//   - uid：用户ID
+//   - postID：博文ID
+//
+// 返回值：
+//   - error：如果发生错误，返回相应错误信息；否则返回 nil
+func (service *PostService) UnlikePost(uid uint64, postID uint64) error {
+	return service.postStore.UnlikePost(postID, uid)
+}
+
+// FavoritePost 收藏博文。
+//
+// 参数：
+//   - uid：用户ID
+//   - postID：博文ID
+//
+// 返回值：
+//   - error：如果发生错误，返回相应错误信息；否则返回 nil
+func (service *PostService) FavoritePost(uid uint64, postID uint64) error {
+	return service.postStore.FavoritePost(postID, uid)
+}
+
+// UnfavoritePost 取消收藏博文。
+//
+// 参数：
+//   - uid：用户ID
+//   - postID：博文ID
+//
+// 返回值：
+//   - error：如果发生错误，返回相应错误信息；否则返回 nil
+func (service *PostService) UnfavoritePost(uid uint64, postID uint64) error {
+	return service.postStore.UnfavoritePost(postID, uid)
+}
+
+// processPostImages 校验博文图片并调整大小。
+//
+// 参数：
+//   - postImages：上传的图片
+//
+// 返回值：
+//   - [][]byte：处理后的图片数据。
+//   - error：如果图片不合法或处理失败，则返回相应的错误信息，否则返回nil。
+func processPostImages(postImages []*multipart.FileHeader) ([][]byte, error) {
+	var converteredImages [][]byte
 	for _, image := range postImages {
-		imageFile, err = image.Open()
+		imageFile, err := image.Open()
 		if err != nil {
-			imageFile.Close()
-			return models.PostInfo{}, err
+			return nil, err
 		}
 
 		// 验证图像文件的有效性，包括尺寸和文件类型等
@@ -96,35 +542,16 @@ func (service *PostService) CreatePost(uid uint64, ipAddr string, postReqInfo ty
 		)
 		if err != nil {
 			imageFile.Close()
-			return models.PostInfo{}, err
+			return nil, err
 		}
 
 		//调整帖子图片的大小
 		converteredImage, err := converters.ResizePostImage(fileType, &imageFile)
+		imageFile.Close()
 		if err != nil {
-			imageFile.Close()
-			return models.PostInfo{}, err
+			return nil, err
 		}
 		converteredImages = append(converteredImages, converteredImage)
-		imageFile.Close()
-	}
-
-	// 调用存储层的方法创建帖子
-	postInfo, err := service.postStore.CreatePost(uid, ipAddr, postReqInfo, converteredImages)
-	if err != nil {
-		return models.PostInfo{}, err
 	}
-	return postInfo, nil
-}
-
-// DeletePost 是用于删除博文的服务方法
-//
-// 参数：
-// - postID uint64：待删除博文的ID
-//
-// 返回值：
-// - error：如果发生错误，返回相应错误信息；否则返回 nil
-func (service *PostService) DeletePost(postID uint64) error {
-	// 调用post存储中的删除post方法
-	return service.postStore.DeletePost(postID)
+	return converteredImages, nil
 }
@@ -0,0 +1,235 @@
+/*
+Package services - NekoBlog backend server services.
+This file is for user relationship related services.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package services
+
+import (
+	"errors"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
+	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/stores"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+)
+
+var (
+	// ErrFollowSelf 不能关注自己
+	ErrFollowSelf = errors.New("cannot follow yourself")
+
+	// ErrBlockSelf 不能屏蔽自己
+	ErrBlockSelf = errors.New("cannot block yourself")
+
+	// ErrUserBlocked 双方之间存在屏蔽关系
+	ErrUserBlocked = errors.New("user is blocked")
+)
+
+// RelationService 用户关系服务
+type RelationService struct {
+	relationStore    *stores.RelationStore
+	userStore        *stores.UserStore
+	timelineStore    *stores.TimelineStore
+	timelineStrategy string
+}
+
+// NewRelationService 返回一个新的用户关系服务实例。
+//
+// 返回值：
+//   - *RelationService：新的用户关系服务实例。
+func (factory *Factory) NewRelationService() *RelationService {
+	return &RelationService{
+		relationStore:    factory.storeFactory.NewRelationStore(),
+		userStore:        factory.storeFactory.NewUserStore(),
+		timelineStore:    factory.storeFactory.NewTimelineStore(),
+		timelineStrategy: factory.timelineStrategy,
+	}
+}
+
+// FollowUser 关注用户。
+//
+// 参数：
+//   - uid：关注者用户ID
+//   - targetUID：被关注者用户ID
+//
+// 返回值：
+//   - error：被关注者不存在时返回 gorm.ErrRecordNotFound，存在屏蔽关系时返回 ErrUserBlocked，
+//     其他错误返回相应的错误信息，否则返回nil。
+func (service *RelationService) FollowUser(uid uint64, targetUID uint64) error {
+	if uid == targetUID {
+		return ErrFollowSelf
+	}
+	if _, err := service.userStore.GetUserByUID(targetUID); err != nil {
+		return err
+	}
+
+	// 存在屏蔽关系时不允许关注
+	blocked, err := service.relationStore.IsBlockedBetween(uid, targetUID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrUserBlocked
+	}
+
+	err = service.relationStore.CreateUserFollow(uid, targetUID)
+	if err != nil {
+		return err
+	}
+
+	// 写扩散模式下将被关注者最近的博文补充到时间线
+	if service.timelineStrategy == consts.TIMELINE_STRATEGY_WRITE {
+		return service.timelineStore.BackfillTimeline(uid, targetUID, consts.TIMELINE_BACKFILL_LIMIT)
+	}
+	return nil
+}
+
+// UnfollowUser 取消关注用户。
+//
+// 参数：
+//   - uid：关注者用户ID
+//   - targetUID：被关注者用户ID
+//
+// 返回值：
+//   - error：如果在取消关注过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *RelationService) UnfollowUser(uid uint64, targetUID uint64) error {
+	err := service.relationStore.DeleteUserFollow(uid, targetUID)
+	if err != nil {
+		return err
+	}
+
+	// 写扩散模式下从时间线中移除被取消关注者的博文
+	if service.timelineStrategy == consts.TIMELINE_STRATEGY_WRITE {
+		return service.timelineStore.RemoveTimelineAuthor(uid, targetUID)
+	}
+	return nil
+}
+
+// BlockUser 屏蔽用户，并解除双方之间的关注关系。
+//
+// 参数：
+//   - uid：屏蔽者用户ID
+//   - targetUID：被屏蔽者用户ID
+//
+// 返回值：
+//   - error：被屏蔽者不存在时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (service *RelationService) BlockUser(uid uint64, targetUID uint64) error {
+	if uid == targetUID {
+		return ErrBlockSelf
+	}
+	if _, err := service.userStore.GetUserByUID(targetUID); err != nil {
+		return err
+	}
+
+	err := service.relationStore.CreateUserBlock(uid, targetUID)
+	if err != nil {
+		return err
+	}
+
+	// 屏蔽会解除双方的关注关系，写扩散模式下同时移除双方时间线中对方的博文
+	if service.timelineStrategy == consts.TIMELINE_STRATEGY_WRITE {
+		if err = service.timelineStore.RemoveTimelineAuthor(uid, targetUID); err != nil {
+			return err
+		}
+		return service.timelineStore.RemoveTimelineAuthor(targetUID, uid)
+	}
+	return nil
+}
+
+// UnblockUser 解除屏蔽用户。
+//
+// 参数：
+//   - uid：屏蔽者用户ID
+//   - targetUID：被屏蔽者用户ID
+//
+// 返回值：
+//   - error：如果在解除过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *RelationService) UnblockUser(uid uint64, targetUID uint64) error {
+	return service.relationStore.DeleteUserBlock(uid, targetUID)
+}
+
+// GetFollowers 分页获取用户的粉丝列表。
+//
+// 参数：
+//   - uid：用户ID
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - []uint64：粉丝的用户ID列表，按关注时间倒序排列。
+//   - *types.Cursor：下一页的游标，没有下一页时为 nil。
+//   - error：用户不存在时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (service *RelationService) GetFollowers(uid uint64, cursor *types.Cursor, limit int) ([]uint64, *types.Cursor, error) {
+	if _, err := service.userStore.GetUserByUID(uid); err != nil {
+		return nil, nil, err
+	}
+
+	follows, err := service.relationStore.GetFollowersByUID(uid, cursor, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	follows, nextCursor := cutPage(follows, limit, followCursor)
+
+	uids := make([]uint64, 0, len(follows))
+	for _, follow := range follows {
+		uids = append(uids, follow.FollowerUID)
+	}
+	return uids, nextCursor, nil
+}
+
+// GetFollowing 分页获取用户的关注列表。
+//
+// 参数：
+//   - uid：用户ID
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - []uint64：被关注者的用户ID列表，按关注时间倒序排列。
+//   - *types.Cursor：下一页的游标，没有下一页时为 nil。
+//   - error：用户不存在时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (service *RelationService) GetFollowing(uid uint64, cursor *types.Cursor, limit int) ([]uint64, *types.Cursor, error) {
+	if _, err := service.userStore.GetUserByUID(uid); err != nil {
+		return nil, nil, err
+	}
+
+	follows, err := service.relationStore.GetFollowingByUID(uid, cursor, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	follows, nextCursor := cutPage(follows, limit, followCursor)
+
+	uids := make([]uint64, 0, len(follows))
+	for _, follow := range follows {
+		uids = append(uids, follow.FolloweeUID)
+	}
+	return uids, nextCursor, nil
+}
+
+// GetUserRelationStats 获取用户的关系统计。
+//
+// 参数：
+//   - uid：用户ID
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//
+// 返回值：
+//   - *types.UserRelationStats：用户关系统计。
+//   - error：如果在查询过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *RelationService) GetUserRelationStats(uid uint64, viewerUID uint64) (*types.UserRelationStats, error) {
+	return service.relationStore.GetUserRelationStats(uid, viewerUID)
+}
+
+// followCursor 根据关注关系生成游标。
+//
+// 参数：
+//   - follow：关注关系
+//
+// 返回值：
+//   - *types.Cursor：指向该关注关系的游标。
+func followCursor(follow models.UserFollow) *types.Cursor {
+	return &types.Cursor{
+		CreatedAt: follow.CreatedAt,
+		ID:        follow.ID,
+	}
+}
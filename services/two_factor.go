@@ -0,0 +1,303 @@
+/*
+Package services - NekoBlog backend server services.
+This file is for two-factor authentication services.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
+	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/encryptors"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/generators"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/parsers"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/validers"
+)
+
+var (
+	// ErrTOTPAlreadyEnabled 已启用两步验证
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+	// ErrTOTPNotEnabled 未启用两步验证
+	ErrTOTPNotEnabled = errors.New("two-factor authentication is not enabled")
+
+	// ErrTOTPNotEnrolled 没有待激活的两步验证密钥
+	ErrTOTPNotEnrolled = errors.New("two-factor authentication is not enrolled")
+
+	// ErrTOTPCodeInvalid 验证码或恢复码无效
+	ErrTOTPCodeInvalid = errors.New("invalid verification code")
+
+	// ErrChallengeTokenInvalid 挑战令牌无效或已过期
+	ErrChallengeTokenInvalid = errors.New("challenge token is invalid or expired")
+)
+
+// EnrollTOTP 注册两步验证密钥，需重新验证密码。
+//
+// 注册后需使用 ActivateTOTP 提交一次验证码才会启用。
+//
+// 参数：
+//   - uid：用户ID
+//   - password：密码
+//
+// 返回值：
+//   - *types.TOTPEnrollment：密钥与 otpauth URI。
+//   - error：如果在注册过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) EnrollTOTP(uid uint64, password string) (*types.TOTPEnrollment, error) {
+	// 重新验证密码
+	userAuthInfo, err := service.reauthenticate(uid, password)
+	if err != nil {
+		return nil, err
+	}
+	if userAuthInfo.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	// 生成并保存密钥
+	secret, err := generators.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	err = service.userStore.SetUserTOTPSecret(uid, secret)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: generators.GenerateTOTPURI(userAuthInfo.UserName, secret),
+	}, nil
+}
+
+// ActivateTOTP 提交验证码以启用两步验证，并生成恢复码。
+//
+// 参数：
+//   - uid：用户ID
+//   - code：验证码
+//
+// 返回值：
+//   - []string：恢复码明文，仅在此时返回一次。
+//   - error：如果在启用过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) ActivateTOTP(uid uint64, code string) ([]string, error) {
+	// 获取用户认证信息
+	userAuthInfo, err := service.userStore.GetUserAuthInfoByUID(uid)
+	if err != nil {
+		return nil, err
+	}
+	if userAuthInfo.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if userAuthInfo.TOTPSecret == nil {
+		return nil, ErrTOTPNotEnrolled
+	}
+
+	// 校验验证码
+	step, ok := validers.ValidateTOTPCode(*userAuthInfo.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, ErrTOTPCodeInvalid
+	}
+
+	// 生成恢复码
+	recoveryCodes, err := generators.GenerateRecoveryCodes(consts.RECOVERY_CODE_COUNT)
+	if err != nil {
+		return nil, err
+	}
+	recoveryCodeHashes := make([]string, 0, len(recoveryCodes))
+	for _, recoveryCode := range recoveryCodes {
+		recoveryCodeHashes = append(recoveryCodeHashes, encryptors.HashToken(normalizeRecoveryCode(recoveryCode)))
+	}
+
+	// 启用两步验证
+	err = service.userStore.EnableUserTOTP(uid, step, recoveryCodeHashes)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTOTPNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// DisableTOTP 关闭两步验证，需重新验证密码并提交验证码或恢复码。
+//
+// 参数：
+//   - uid：用户ID
+//   - password：密码
+//   - code：验证码或恢复码
+//
+// 返回值：
+//   - error：如果在关闭过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) DisableTOTP(uid uint64, password string, code string) error {
+	// 重新验证密码
+	userAuthInfo, err := service.reauthenticate(uid, password)
+	if err != nil {
+		return err
+	}
+	if !userAuthInfo.TOTPEnabled {
+		return ErrTOTPNotEnabled
+	}
+
+	// 校验验证码
+	err = service.verifySecondFactor(userAuthInfo, code)
+	if err != nil {
+		return err
+	}
+
+	return service.userStore.DisableUserTOTP(uid)
+}
+
+// LoginUserWithTOTP 使用挑战令牌与验证码或恢复码完成两步验证登录。
+//
+// 参数：
+//   - challengeToken：密码验证通过后获得的挑战令牌
+//   - code：验证码或恢复码
+//   - ip：登录IP
+//   - app：登录应用
+//   - device：登录设备
+//
+// 返回值：
+//   - *types.TokenPair：访问令牌与刷新令牌
+//   - error：如果在登录过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) LoginUserWithTOTP(challengeToken string, code string, ip string, app string, device string) (*types.TokenPair, error) {
+	// 解析挑战令牌
+	claims, err := parsers.ParseChallengeToken(service.tokenSettings, challengeToken)
+	if err != nil {
+		return nil, ErrChallengeTokenInvalid
+	}
+
+	// 预留IP登录尝试
+	ipKey := ipLoginAttemptKey(ip)
+	err = service.reserveIPLoginAttempt(ipKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取用户认证信息
+	userAuthInfo, err := service.userStore.GetUserAuthInfoByUID(claims.UID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		service.releaseLoginAttempts(ipKey)
+		return nil, ErrChallengeTokenInvalid
+	}
+	if err != nil {
+		service.releaseLoginAttempts(ipKey)
+		return nil, err
+	}
+	if !userAuthInfo.TOTPEnabled {
+		service.releaseLoginAttempts(ipKey)
+		return nil, ErrTOTPNotEnabled
+	}
+
+	// 构造登录日志
+	userLoginLog := newUserLoginLog(userAuthInfo.UID, ip, app, device)
+
+	// 预留账户登录尝试
+	accountKey := accountLoginAttemptKey(userAuthInfo.UID)
+	err = service.reserveAccountLoginAttempt(accountKey)
+	var lockedErr *LoginLockedError
+	if errors.As(err, &lockedErr) {
+		service.releaseLoginAttempts(ipKey)
+		return nil, service.recordLoginFailure(userLoginLog, consts.LOGIN_FAILURE_REASON_LOCKED, err)
+	}
+	if err != nil {
+		service.releaseLoginAttempts(ipKey)
+		return nil, err
+	}
+
+	// 校验验证码
+	err = service.verifySecondFactor(userAuthInfo, code)
+	if errors.Is(err, ErrTOTPCodeInvalid) {
+		return nil, service.recordLoginFailure(userLoginLog, consts.LOGIN_FAILURE_REASON_TOTP, err, ipKey, accountKey)
+	}
+	if err != nil {
+		service.releaseLoginAttempts(ipKey, accountKey)
+		return nil, err
+	}
+
+	// 验证码正确的尝试不计入IP限制
+	service.releaseLoginAttempts(ipKey)
+
+	// 创建会话
+	return service.createLoginSession(userAuthInfo, userLoginLog, accountKey)
+}
+
+// reauthenticate 重新验证当前用户的密码。
+//
+// 参数：
+//   - uid：用户ID
+//   - password：密码
+//
+// 返回值：
+//   - *models.UserAuthInfo：用户认证信息。
+//   - error：如果密码错误，则返回 ErrPasswordIncorrect；如果在验证过程中发生其他错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) reauthenticate(uid uint64, password string) (*models.UserAuthInfo, error) {
+	userAuthInfo, err := service.userStore.GetUserAuthInfoByUID(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	err = encryptors.CompareHashPassword(userAuthInfo.PasswordHash, password, userAuthInfo.Salt)
+	if err != nil {
+		return nil, ErrPasswordIncorrect
+	}
+	return userAuthInfo, nil
+}
+
+// verifySecondFactor 校验验证码或恢复码。
+//
+// 纯数字且长度与验证码一致时按验证码校验，否则按恢复码校验。验证码与恢复码均只能使用一次。
+//
+// 参数：
+//   - userAuthInfo：用户认证信息
+//   - code：验证码或恢复码
+//
+// 返回值：
+//   - error：如果验证码或恢复码无效，则返回 ErrTOTPCodeInvalid；如果在校验过程中发生其他错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) verifySecondFactor(userAuthInfo *models.UserAuthInfo, code string) error {
+	code = strings.TrimSpace(code)
+
+	// 恢复码
+	if len(code) != consts.TOTP_DIGITS || strings.Trim(code, "0123456789") != "" {
+		err := service.userStore.ConsumeUserRecoveryCode(userAuthInfo.UID, encryptors.HashToken(normalizeRecoveryCode(code)))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTOTPCodeInvalid
+		}
+		return err
+	}
+
+	// 验证码
+	if userAuthInfo.TOTPSecret == nil {
+		return ErrTOTPCodeInvalid
+	}
+	step, ok := validers.ValidateTOTPCode(*userAuthInfo.TOTPSecret, code, time.Now())
+	if !ok {
+		return ErrTOTPCodeInvalid
+	}
+	err := service.userStore.UseUserTOTPStep(userAuthInfo.UID, step)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 验证码已被使用
+		return ErrTOTPCodeInvalid
+	}
+	return err
+}
+
+// normalizeRecoveryCode 规范化恢复码，忽略大小写与分隔符。
+//
+// 参数：
+//   - code：恢复码
+//
+// 返回值：
+//   - string：规范化后的恢复码。
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,112 @@
+/*
+Package services - NekoBlog backend server services.
+This file is for two-factor authentication service tests.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
+	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/stores"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/encryptors"
+)
+
+// totpStepDriver 仅模拟 UseUserTOTPStep 条件更新的数据库驱动
+type totpStepDriver struct {
+	mu        sync.Mutex
+	lastSteps map[int64]int64
+}
+
+func (d *totpStepDriver) Open(string) (driver.Conn, error) { return &totpStepConn{d}, nil }
+
+type totpStepConn struct{ driver *totpStepDriver }
+
+func (c *totpStepConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+func (c *totpStepConn) Close() error              { return nil }
+func (c *totpStepConn) Begin() (driver.Tx, error) { return nil, errors.New("begin is not supported") }
+
+// ExecContext 执行 UPDATE ... SET totp_last_step = $1 WHERE uid = $2 AND totp_last_step < $3
+func (c *totpStepConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if !strings.Contains(query, "totp_last_step") || len(args) != 3 {
+		return nil, errors.New("unexpected query: " + query)
+	}
+	step, uid := args[0].Value.(int64), args[1].Value.(int64)
+
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	if c.driver.lastSteps[uid] >= args[2].Value.(int64) {
+		return driver.RowsAffected(0), nil
+	}
+	c.driver.lastSteps[uid] = step
+	return driver.RowsAffected(1), nil
+}
+
+var registerTOTPStepDriver sync.Once
+
+// newTOTPTestService 创建使用模拟数据库的 UserService。
+func newTOTPTestService(t *testing.T) *UserService {
+	t.Helper()
+	registerTOTPStepDriver.Do(func() {
+		sql.Register("totp-step", &totpStepDriver{lastSteps: make(map[int64]int64)})
+	})
+	sqlDB, err := sql.Open("totp-step", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &UserService{userStore: stores.NewFactory(db, nil).NewUserStore()}
+}
+
+// TestVerifySecondFactorRejectsReplay 校验同一时间步及更早时间步的验证码不能再次使用。
+func TestVerifySecondFactorRejectsReplay(t *testing.T) {
+	service := newTOTPTestService(t)
+	key := []byte("12345678901234567890")
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	current := time.Now().Unix() / consts.TOTP_PERIOD
+	codeAt := func(step int64) string {
+		return encryptors.ComputeHOTP(key, uint64(step), consts.TOTP_DIGITS)
+	}
+
+	cases := []struct {
+		name string
+		uid  uint64
+		code string
+		want error
+	}{
+		{"previous step accepted", 1, codeAt(current - 1), nil},
+		{"previous step replayed", 1, codeAt(current - 1), ErrTOTPCodeInvalid},
+		{"current step accepted", 1, codeAt(current), nil},
+		{"current step replayed", 1, codeAt(current), ErrTOTPCodeInvalid},
+		{"older step after newer", 1, codeAt(current - 1), ErrTOTPCodeInvalid},
+		{"other user unaffected", 2, codeAt(current), nil},
+	}
+	for _, c := range cases {
+		userAuthInfo := &models.UserAuthInfo{UID: c.uid, TOTPSecret: &secret, TOTPEnabled: true}
+		err := service.verifySecondFactor(userAuthInfo, c.code)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: verifySecondFactor() error = %v, want %v", c.name, err, c.want)
+		}
+	}
+}
@@ -14,10 +14,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 
 	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
 	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/notifiers"
 	"github.com/Kirisakiii/neko-micro-blog-backend/stores"
 	"github.com/Kirisakiii/neko-micro-blog-backend/types"
 	"github.com/Kirisakiii/neko-micro-blog-backend/utils/converters"
@@ -26,9 +28,48 @@ import (
 	"github.com/Kirisakiii/neko-micro-blog-backend/utils/validers"
 )
 
+var (
+	// ErrRefreshTokenInvalid 刷新令牌不存在或已过期
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid")
+
+	// ErrRefreshTokenReused 刷新令牌被重复使用，所属会话已被撤销
+	ErrRefreshTokenReused = errors.New("refresh token has been reused, session revoked")
+
+	// ErrPasswordIncorrect 密码错误
+	ErrPasswordIncorrect = errors.New("incorrect password")
+
+	// ErrPasswordInvalid 密码不符合密码规则
+	ErrPasswordInvalid = errors.New("invalid password")
+
+	// ErrUsernameExists 用户名已被注册
+	ErrUsernameExists = errors.New("username already exists")
+
+	// ErrEmailInvalid 邮箱地址不合法
+	ErrEmailInvalid = errors.New("invalid email")
+
+	// ErrEmailInUse 邮箱已被其他用户使用
+	ErrEmailInUse = errors.New("email already in use")
+
+	// ErrOneTimeTokenInvalid 一次性令牌不存在、已使用或已过期
+	ErrOneTimeTokenInvalid = errors.New("token is invalid or expired")
+)
+
+// LoginLockedError 登录失败次数过多导致的暂时锁定
+type LoginLockedError struct {
+	RetryAfter time.Duration // 距离允许再次尝试的时长
+}
+
+// Error 实现 error 接口
+func (err *LoginLockedError) Error() string {
+	return "too many failed login attempts, please retry later"
+}
+
 // UserService 用户服务
 type UserService struct {
-	userStore *stores.UserStore
+	userStore          *stores.UserStore
+	tokenSettings      *types.TokenSettings
+	passwordHashParams *types.PasswordHashParams
+	notifier           notifiers.Notifier
 }
 
 // NewUserService 返回一个新的 UserService 实例。
@@ -37,7 +78,10 @@ type UserService struct {
 //   - *UserService：新的 UserService 实例。
 func (factory *Factory) NewUserService() *UserService {
 	return &UserService{
-		userStore: factory.storeFactory.NewUserStore(),
+		userStore:          factory.storeFactory.NewUserStore(),
+		tokenSettings:      factory.tokenSettings,
+		passwordHashParams: factory.passwordHashParams,
+		notifier:           factory.notifier,
 	}
 }
 
@@ -82,7 +126,7 @@ func (service *UserService) GetUserInfoByUsername(username string) (*models.User
 //   - password：密码
 //
 // 返回值：
-//   - error：如果在注册过程中发生错误，则返回相应的错误信息，否则返回nil。
+//   - error：用户名已被注册时返回 ErrUsernameExists，其他错误返回相应的错误信息，否则返回nil。
 func (service *UserService) RegisterUser(username string, password string) error {
 	// 验证用户名和密码是否合法
 	if !validers.IsValidUsername(username) {
@@ -92,24 +136,17 @@ func (service *UserService) RegisterUser(username string, password string) error
 		return errors.New("invalid password")
 	}
 
-	// 检验用户名是否重复
-	_, err := service.userStore.GetUserByUsername(username)
-	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return errors.New("username already exists")
-	}
-
-	// 生成盐和哈希密码
-	salt, err := generators.GenerateSalt(consts.SALT_LENGTH)
-	if err != nil {
-		return err
-	}
-	hashedPassword, err := encryptors.HashPassword(password, salt)
+	// 生成哈希密码
+	hashedPassword, err := encryptors.HashPassword(password, service.passwordHashParams)
 	if err != nil {
 		return err
 	}
 
-	// 注册用户
-	err = service.userStore.RegisterUserByUsername(username, salt, hashedPassword)
+	// 注册用户，依赖唯一约束判断用户名是否重复
+	err = service.userStore.RegisterUserByUsername(username, hashedPassword)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrUsernameExists
+	}
 	if err != nil {
 		return err
 	}
@@ -119,24 +156,179 @@ func (service *UserService) RegisterUser(username string, password string) error
 
 // LoginUser 用户登录。
 //
+// 登录成功后创建新的会话，并签发访问令牌与刷新令牌。
+// 用户开启两步验证时不创建会话，而是返回挑战令牌，需通过 LoginUserWithTOTP 完成登录。
+//
 // 参数：
 //   - username：用户名
 //   - password：密码
 //   - ip：登录IP
+//   - app：登录应用
+//   - device：登录设备
 //
 // 返回值：
-//   - string：Bearer Token
+//   - *types.TokenPair：访问令牌与刷新令牌，需要两步验证时为 nil
+//   - *types.LoginChallenge：两步验证挑战，无需两步验证时为 nil
 //   - error：如果在登录过程中发生错误，则返回相应的错误信息，否则返回nil。
-func (service *UserService) LoginUser(username string, password string, ip string, app string, device string) (string, error) {
+func (service *UserService) LoginUser(username string, password string, ip string, app string, device string) (*types.TokenPair, *types.LoginChallenge, error) {
+	// 预留IP登录尝试
+	ipKey := ipLoginAttemptKey(ip)
+	err := service.reserveIPLoginAttempt(ipKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// 获取用户认证信息
 	userAuthInfo, err := service.userStore.GetUserAuthInfoByUsername(username)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 记录不存在的用户名，以便计入IP限制
+		userLoginLog := newUserLoginLog(0, ip, app, device)
+		return nil, nil, service.recordLoginFailure(userLoginLog, consts.LOGIN_FAILURE_REASON_USER_NOT_FOUND, err, ipKey)
+	}
 	if err != nil {
-		return "", err
+		service.releaseLoginAttempts(ipKey)
+		return nil, nil, err
 	}
 
 	// 构造登录日志
-	userLoginLog := &models.UserLoginLog{
-		UID:         userAuthInfo.UID,
+	userLoginLog := newUserLoginLog(userAuthInfo.UID, ip, app, device)
+
+	// 预留账户登录尝试
+	accountKey := accountLoginAttemptKey(userAuthInfo.UID)
+	err = service.reserveAccountLoginAttempt(accountKey)
+	var lockedErr *LoginLockedError
+	if errors.As(err, &lockedErr) {
+		service.releaseLoginAttempts(ipKey)
+		return nil, nil, service.recordLoginFailure(userLoginLog, consts.LOGIN_FAILURE_REASON_LOCKED, err)
+	}
+	if err != nil {
+		service.releaseLoginAttempts(ipKey)
+		return nil, nil, err
+	}
+
+	// 验证密码
+	err = encryptors.CompareHashPassword(userAuthInfo.PasswordHash, password, userAuthInfo.Salt)
+	if err != nil {
+		return nil, nil, service.recordLoginFailure(userLoginLog, consts.LOGIN_FAILURE_REASON_PASSWORD, errors.New("password error"), ipKey, accountKey)
+	}
+
+	// 密码正确的尝试不计入IP限制
+	service.releaseLoginAttempts(ipKey)
+
+	// 使用当前参数升级哈希，升级失败不影响本次登录，下次登录时会再次尝试
+	if encryptors.NeedsRehash(userAuthInfo.PasswordHash, service.passwordHashParams) {
+		hashedPassword, err := encryptors.HashPassword(password, service.passwordHashParams)
+		if err == nil {
+			_ = service.userStore.UpgradeUserPasswordHash(userAuthInfo.UID, userAuthInfo.PasswordHash, hashedPassword)
+		}
+	}
+
+	// 开启两步验证时返回挑战令牌，两步验证完成前账户计数不清零，避免借助正确的密码无限次尝试验证码
+	if userAuthInfo.TOTPEnabled {
+		service.releaseLoginAttempts(accountKey)
+		challengeToken, claims, err := generators.GenerateChallengeToken(service.tokenSettings, userAuthInfo.UID, userAuthInfo.UserName)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &types.LoginChallenge{
+			ChallengeToken: challengeToken,
+			ExpireTime:     claims.ExpiresAt.Time,
+		}, nil
+	}
+
+	// 创建会话
+	tokenPair, err := service.createLoginSession(userAuthInfo, userLoginLog, accountKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokenPair, nil, nil
+}
+
+// createLoginSession 为通过验证的用户创建会话并签发令牌。
+//
+// 创建成功后清除账户的登录尝试计数，失败时释放本次预留的尝试。
+//
+// 参数：
+//   - userAuthInfo：用户认证信息
+//   - userLoginLog：本次登录的登录日志，创建完成后写入数据库
+//   - accountKey：账户登录尝试的计数键
+//
+// 返回值：
+//   - *types.TokenPair：访问令牌与刷新令牌
+//   - error：如果在创建过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) createLoginSession(userAuthInfo *models.UserAuthInfo, userLoginLog *models.UserLoginLog, accountKey string) (*types.TokenPair, error) {
+	tokenPair, err := service.issueLoginSession(userAuthInfo, userLoginLog)
+	if err != nil {
+		service.releaseLoginAttempts(accountKey)
+		return nil, err
+	}
+
+	// 登录成功后重新计数，清除失败仅会使下次登录的计数偏高，不影响本次登录
+	_ = service.userStore.ResetLoginAttempts(accountKey)
+	return tokenPair, nil
+}
+
+// issueLoginSession 创建会话、签发令牌并写入登录日志。
+//
+// 参数：
+//   - userAuthInfo：用户认证信息
+//   - userLoginLog：本次登录的登录日志，创建完成后写入数据库
+//
+// 返回值：
+//   - *types.TokenPair：访问令牌与刷新令牌
+//   - error：如果在创建过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) issueLoginSession(userAuthInfo *models.UserAuthInfo, userLoginLog *models.UserLoginLog) (*types.TokenPair, error) {
+	// 检查会话是否达到上限
+	sessions, err := service.userStore.GetUserSessionsByUID(userAuthInfo.UID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) >= service.tokenSettings.MaxTokensPerUser {
+		// 清除最早的会话
+		err = service.userStore.BanUserSession(sessions[len(sessions)-1].SessionID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 签发令牌
+	sessionID := uuid.New().String()
+	tokenPair, refreshToken, err := service.issueTokenPair(userAuthInfo.UID, userAuthInfo.UserName, sessionID)
+	if err != nil {
+		return nil, service.recordLoginFailure(userLoginLog, "token generation error", err)
+	}
+
+	// 保存刷新令牌
+	err = service.userStore.CreateUserRefreshToken(refreshToken)
+	if err != nil {
+		return nil, service.recordLoginFailure(userLoginLog, "token creation error", err)
+	}
+
+	// 更新登录日志
+	userLoginLog.IsSucceed = true
+	userLoginLog.BearerToken = tokenPair.AccessToken
+	userLoginLog.SessionID = sessionID
+	err = service.userStore.CreateUserLoginLog(userLoginLog)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokenPair, nil
+}
+
+// newUserLoginLog 构造一条尚未成功的登录日志。
+//
+// 参数：
+//   - uid：用户ID，用户不存在时为 0
+//   - ip：登录IP
+//   - app：登录应用
+//   - device：登录设备
+//
+// 返回值：
+//   - *models.UserLoginLog：登录日志。
+func newUserLoginLog(uid uint64, ip string, app string, device string) *models.UserLoginLog {
+	return &models.UserLoginLog{
+		UID:         uid,
 		LoginTime:   time.Now(),
 		LoginIP:     ip,
 		Application: app,
@@ -144,62 +336,316 @@ func (service *UserService) LoginUser(username string, password string, ip strin
 		IsSucceed:   false,
 		IfChecked:   false,
 	}
+}
 
-	// 验证密码
-	err = encryptors.CompareHashPassword(userAuthInfo.PasswordHash, password, userAuthInfo.Salt)
-	if err != nil {
-		userLoginLog.Reason = "password error"
-		inner_err := service.userStore.CreateUserLoginLog(userLoginLog)
+// recordLoginFailure 记录登录失败日志，并将预留的登录尝试记为失败。
+//
+// 参数：
+//   - userLoginLog：登录日志
+//   - reason：失败原因
+//   - err：导致失败的错误
+//   - attemptKeys：需要记为失败的登录尝试计数键
+//
+// 返回值：
+//   - error：导致失败的错误，写入日志或计数失败时一并返回。
+func (service *UserService) recordLoginFailure(userLoginLog *models.UserLoginLog, reason string, err error, attemptKeys ...string) error {
+	for _, key := range attemptKeys {
+		inner_err := service.userStore.RecordLoginAttemptFailure(key, userLoginLog.LoginTime)
 		if inner_err != nil {
-			return "", errors.Join(err, inner_err)
+			err = errors.Join(err, inner_err)
 		}
-		return "", errors.New("password error")
 	}
 
-	// 检查令牌是否达到上限
-	avaliableTokens, err := service.userStore.GetUserAvaliableTokensByUsername(userAuthInfo.UserName)
+	userLoginLog.Reason = reason
+	inner_err := service.userStore.CreateUserLoginLog(userLoginLog)
+	if inner_err != nil {
+		return errors.Join(err, inner_err)
+	}
+	return err
+}
+
+// ipLoginAttemptKey 获取IP登录尝试的计数键。
+//
+// 参数：
+//   - ip：登录IP
+//
+// 返回值：
+//   - string：计数键。
+func ipLoginAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// accountLoginAttemptKey 获取账户登录尝试的计数键。
+//
+// 参数：
+//   - uid：用户ID
+//
+// 返回值：
+//   - string：计数键。
+func accountLoginAttemptKey(uid uint64) string {
+	return "uid:" + strconv.FormatUint(uid, 10)
+}
+
+// reserveIPLoginAttempt 预留一次IP登录尝试，并检查IP在时间窗口内的尝试次数是否超出限制。
+//
+// 进行中的尝试同样计入限制，因此并发请求无法在失败被记录前绕过限制。
+//
+// 参数：
+//   - ipKey：IP登录尝试的计数键
+//
+// 返回值：
+//   - error：如果IP被限制，则返回 *LoginLockedError；如果在检查过程中发生其他错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) reserveIPLoginAttempt(ipKey string) error {
+	now := time.Now()
+	reservation, err := service.userStore.ReserveLoginAttempt(ipKey, now, consts.LOGIN_FAILURE_WINDOW)
 	if err != nil {
-		return "", err
+		return err
+	}
+	if reservation.Attempts <= consts.LOGIN_IP_FAILURE_LIMIT {
+		return nil
 	}
-	if len(avaliableTokens) >= consts.MAX_TOKENS_PER_USER {
-		// 清除最早的令牌
-		err = service.userStore.BanUserToken(avaliableTokens[0].Token)
+
+	// 被拒绝的尝试不占用计数，时间窗口结束后即可重试
+	service.releaseLoginAttempts(ipKey)
+	return &LoginLockedError{RetryAfter: reservation.WindowStart.Add(consts.LOGIN_FAILURE_WINDOW).Sub(now)}
+}
+
+// reserveAccountLoginAttempt 预留一次账户登录尝试，并检查账户是否需要等待或已被锁定。
+//
+// 连续失败达到 LOGIN_DELAY_THRESHOLD 次后，每次尝试前需等待的时长从 LOGIN_BASE_DELAY 起逐次翻倍，
+// 达到 LOGIN_LOCK_THRESHOLD 次后锁定 LOGIN_LOCK_DURATION。进行中的尝试按失败计算，成功登录后重新计数。
+//
+// 参数：
+//   - accountKey：账户登录尝试的计数键
+//
+// 返回值：
+//   - error：如果账户需要等待，则返回 *LoginLockedError；如果在检查过程中发生其他错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) reserveAccountLoginAttempt(accountKey string) error {
+	now := time.Now()
+	reservation, err := service.userStore.ReserveLoginAttempt(accountKey, now, consts.LOGIN_FAILURE_WINDOW)
+	if err != nil {
+		return err
+	}
+	failures := reservation.Attempts - 1
+	if failures < consts.LOGIN_DELAY_THRESHOLD {
+		return nil
+	}
+
+	// 计算需要等待的时长
+	var delay time.Duration
+	if failures >= consts.LOGIN_LOCK_THRESHOLD {
+		delay = consts.LOGIN_LOCK_DURATION
+	} else {
+		delay = consts.LOGIN_BASE_DELAY << (failures - consts.LOGIN_DELAY_THRESHOLD)
+		delay = min(delay, consts.LOGIN_MAX_DELAY)
+	}
+
+	// 尚无失败记录时说明其余尝试仍在进行中，自当前时间起计算
+	lastFailureTime := now
+	if reservation.LastFailureAt != nil {
+		lastFailureTime = *reservation.LastFailureAt
+	}
+	retryAfter := lastFailureTime.Add(delay).Sub(now)
+	if retryAfter <= 0 {
+		return nil
+	}
+
+	// 被拒绝的尝试不占用计数
+	service.releaseLoginAttempts(accountKey)
+	return &LoginLockedError{RetryAfter: retryAfter}
+}
+
+// releaseLoginAttempts 释放不计为失败的登录尝试。
+//
+// 释放失败仅会使计数偏高，不会放宽限制，因此忽略错误。
+//
+// 参数：
+//   - keys：登录尝试的计数键
+func (service *UserService) releaseLoginAttempts(keys ...string) {
+	for _, key := range keys {
+		_ = service.userStore.ReleaseLoginAttempt(key)
+	}
+}
+
+// RefreshUserToken 使用刷新令牌换取新的令牌对。
+//
+// 每个刷新令牌只能使用一次，使用后即被轮换。已轮换的刷新令牌再次出现时视为令牌泄露，
+// 其所属会话的全部刷新令牌将被撤销。
+//
+// 参数：
+//   - refreshToken：刷新令牌
+//
+// 返回值：
+//   - *types.TokenPair：新的访问令牌与刷新令牌
+//   - error：如果刷新令牌无效或已被使用，则返回相应的错误信息，否则返回nil。
+func (service *UserService) RefreshUserToken(refreshToken string) (*types.TokenPair, error) {
+	// 查找刷新令牌
+	oldToken, err := service.userStore.GetUserRefreshTokenByHash(encryptors.HashToken(refreshToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 已使用的令牌再次出现，撤销整个会话
+	if oldToken.IsUsed {
+		err = service.userStore.BanUserSession(oldToken.SessionID)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
+		return nil, ErrRefreshTokenReused
 	}
 
-	// 生成令牌
-	token, claims, err := generators.GenerateToken(userAuthInfo.UID, username)
+	// 检查是否过期
+	if time.Now().After(oldToken.ExpireTime) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	// 签发新的令牌对
+	tokenPair, newToken, err := service.issueTokenPair(oldToken.UID, oldToken.Username, oldToken.SessionID)
 	if err != nil {
-		userLoginLog.Reason = "token generation error"
-		inner_err := service.userStore.CreateUserLoginLog(userLoginLog)
-		if inner_err != nil {
-			return "", errors.Join(err, inner_err)
+		return nil, err
+	}
+
+	// 轮换刷新令牌
+	err = service.userStore.RotateUserRefreshToken(oldToken.ID, newToken)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 并发请求已使用了该令牌，同样视为重放
+		err = service.userStore.BanUserSession(oldToken.SessionID)
+		if err != nil {
+			return nil, err
 		}
-		return "", err
+		return nil, ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	// 创建可用令牌
-	err = service.userStore.CreateUserAvaliableToken(token, claims)
+	return tokenPair, nil
+}
+
+// issueTokenPair 为指定会话签发访问令牌与刷新令牌。
+//
+// 参数：
+//   - uid：用户ID
+//   - username：用户名
+//   - sessionID：会话ID
+//
+// 返回值：
+//   - *types.TokenPair：访问令牌与刷新令牌
+//   - *models.UserRefreshToken：待保存的刷新令牌记录
+//   - error：如果在签发过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) issueTokenPair(uid uint64, username string, sessionID string) (*types.TokenPair, *models.UserRefreshToken, error) {
+	// 生成访问令牌
+	accessToken, claims, err := generators.GenerateToken(service.tokenSettings, uid, username, sessionID)
 	if err != nil {
-		userLoginLog.Reason = "token creation error"
-		inner_err := service.userStore.CreateUserLoginLog(userLoginLog)
-		if inner_err != nil {
-			return "", errors.Join(err, inner_err)
-		}
-		return "", err
+		return nil, nil, err
 	}
 
-	// 更新登录日志
-	userLoginLog.IsSucceed = true
-	userLoginLog.BearerToken = token
-	err = service.userStore.CreateUserLoginLog(userLoginLog)
+	// 生成刷新令牌
+	refreshToken, err := generators.GenerateSecretToken(consts.REFRESH_TOKEN_BYTES)
 	if err != nil {
-		return "", err
+		return nil, nil, err
 	}
+	refreshExpireTime := time.Now().Add(service.tokenSettings.RefreshExpireDuration)
 
-	return token, nil
+	tokenPair := &types.TokenPair{
+		AccessToken:       accessToken,
+		AccessExpireTime:  claims.ExpiresAt.Time,
+		RefreshToken:      refreshToken,
+		RefreshExpireTime: refreshExpireTime,
+	}
+	refreshTokenModel := &models.UserRefreshToken{
+		UID:        uid,
+		Username:   username,
+		SessionID:  sessionID,
+		TokenHash:  encryptors.HashToken(refreshToken),
+		ExpireTime: refreshExpireTime,
+	}
+	return tokenPair, refreshTokenModel, nil
+}
+
+// LogoutUser 注销当前会话。
+//
+// 参数：
+//   - sessionID：当前会话ID
+//
+// 返回值：
+//   - error：如果在注销过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) LogoutUser(sessionID string) error {
+	return service.userStore.BanUserSession(sessionID)
+}
+
+// LogoutUserAll 注销用户的所有会话。
+//
+// 参数：
+//   - uid：用户ID
+//
+// 返回值：
+//   - error：如果在注销过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) LogoutUserAll(uid uint64) error {
+	return service.userStore.BanUserSessionsByUID(uid, "")
+}
+
+// GetUserSessions 获取用户的有效会话列表。
+//
+// 参数：
+//   - uid：用户ID
+//
+// 返回值：
+//   - []types.UserSession：用户的有效会话列表。
+//   - error：如果在获取过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) GetUserSessions(uid uint64) ([]types.UserSession, error) {
+	return service.userStore.GetUserSessionsByUID(uid)
+}
+
+// RevokeUserSession 撤销用户的指定会话。
+//
+// 参数：
+//   - uid：用户ID
+//   - sessionID：会话ID
+//
+// 返回值：
+//   - error：如果在撤销过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) RevokeUserSession(uid uint64, sessionID string) error {
+	return service.userStore.BanUserSessionByID(uid, sessionID)
+}
+
+// RevokeOtherUserSessions 撤销除当前会话外的所有会话。
+//
+// 参数：
+//   - uid：用户ID
+//   - currentSessionID：当前会话ID
+//
+// 返回值：
+//   - error：如果在撤销过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) RevokeOtherUserSessions(uid uint64, currentSessionID string) error {
+	return service.userStore.BanUserSessionsByUID(uid, currentSessionID)
+}
+
+// GetUncheckedLoginEvents 获取用户尚未确认的登录事件。
+//
+// 参数：
+//   - uid：用户ID
+//
+// 返回值：
+//   - []types.LoginEvent：未确认的登录事件，最多返回 MAX_PAGE_LIMIT 条。
+//   - error：如果在获取过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) GetUncheckedLoginEvents(uid uint64) ([]types.LoginEvent, error) {
+	return service.userStore.GetUncheckedLoginEventsByUID(uid, consts.MAX_PAGE_LIMIT)
+}
+
+// AcknowledgeLoginEvents 确认登录事件，确认后不再返回。
+//
+// 参数：
+//   - uid：用户ID
+//   - ids：登录事件ID列表，为空时确认全部事件
+//
+// 返回值：
+//   - error：如果在确认过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) AcknowledgeLoginEvents(uid uint64, ids []uint64) error {
+	return service.userStore.CheckUserLoginLogs(uid, ids)
 }
 
 // UserUploadAvatar 用户上传头像。
@@ -220,10 +666,10 @@ func (service *UserService) UserUploadAvatar(uid uint64, fileHeader *multipart.F
 
 	// 校验头像
 	fileType, err := validers.ValidImageFile(
-		fileHeader, 
-		&file, 
-		consts.MIN_AVATAR_SIZE, 
-		consts.MIN_AVATAR_SIZE, 
+		fileHeader,
+		&file,
+		consts.MIN_AVATAR_SIZE,
+		consts.MIN_AVATAR_SIZE,
 		consts.MAX_AVATAR_FILE_SIZE,
 	)
 	if err != nil {
@@ -246,18 +692,27 @@ func (service *UserService) UserUploadAvatar(uid uint64, fileHeader *multipart.F
 	return service.userStore.SaveUserAvatarByUID(uid, sb.String(), resizedAvatar)
 }
 
-//	UserUpdatePassword 修改密码
+// UserUpdatePassword 修改密码。
 //
-//	参数：
-//	- username: 用户名
-//	- password: 密码
-//	- newPassword: 新的密码
+// 修改成功后使用当前参数生成新的哈希，并撤销该用户的其他会话。
+//
+// 参数：
+//   - uid：用户ID
+//   - sessionID：当前会话ID
+//   - password：原密码
+//   - newPassword：新密码
+//   - keepCurrentSession：是否保留当前会话，为 false 时撤销全部会话
 //
 // 返回值：
-//   - error：如果在上传过程中发生错误，则返回相应的错误信息，否则返回nil。
-func (service *UserService) UserUpdatePassword(username string, password string, newPassword string) error {
+//   - error：如果在修改过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) UserUpdatePassword(uid uint64, sessionID string, password string, newPassword string, keepCurrentSession bool) error {
+	// 校验新密码
+	if !validers.IsValidPassword(newPassword) {
+		return ErrPasswordInvalid
+	}
+
 	// 获取用户认证信息
-	userAuthInfo, err := service.userStore.GetUserAuthInfoByUsername(username)
+	userAuthInfo, err := service.userStore.GetUserAuthInfoByUID(uid)
 	if err != nil {
 		return err
 	}
@@ -265,23 +720,198 @@ func (service *UserService) UserUpdatePassword(username string, password string,
 	// 验证用户密码
 	err = encryptors.CompareHashPassword(userAuthInfo.PasswordHash, password, userAuthInfo.Salt)
 	if err != nil {
-		// 密码验证失败，返回错误
-		return errors.New("incorrect password")
+		return ErrPasswordIncorrect
 	}
 
-	// 取新密码哈希
-	hashedNewPassword, err := encryptors.HashPassword(newPassword, userAuthInfo.Salt)
+	// 生成新的哈希密码
+	hashedNewPassword, err := encryptors.HashPassword(newPassword, service.passwordHashParams)
 	if err != nil {
 		return err
 	}
 
-	//更新密码
-	err = service.userStore.UpdateUserPasswordByUsername(userAuthInfo.UserName, hashedNewPassword)
+	// 更新密码并撤销会话
+	exceptSessionID := ""
+	if keepCurrentSession {
+		exceptSessionID = sessionID
+	}
+	return service.userStore.UpdateUserPasswordByUID(uid, hashedNewPassword, exceptSessionID)
+}
+
+// UpdateUserEmail 修改用户邮箱，并向新邮箱发送验证令牌。
+//
+// 新邮箱在验证通过前仅保存在验证令牌中，用户当前邮箱保持不变。
+//
+// 参数：
+//   - uid：用户ID
+//   - email：新邮箱
+//
+// 返回值：
+//   - error：如果在修改过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) UpdateUserEmail(uid uint64, email string) error {
+	// 校验邮箱
+	if !validers.IsValidEmail(email) {
+		return ErrEmailInvalid
+	}
+
+	// 检验邮箱是否被使用
+	user, err := service.userStore.GetUserByEmail(email)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+	case err != nil:
+		return err
+	case uint64(user.ID) != uid:
+		return ErrEmailInUse
+	case user.EmailVerified:
+		// 已验证的相同邮箱无需重复验证
+		return nil
+	}
+
+	// 发送验证令牌
+	token, err := service.issueOneTimeToken(uid, consts.ONE_TIME_TOKEN_PURPOSE_EMAIL_VERIFICATION, email, consts.EMAIL_VERIFICATION_TOKEN_EXPIRE)
 	if err != nil {
 		return err
 	}
+	return service.notifier.Send(&notifiers.Message{
+		To:      email,
+		Subject: "验证邮箱",
+		Body: "你正在为 NekoBlog 账户绑定此邮箱。\n" +
+			"验证令牌：" + token + "\n" +
+			"该令牌 24 小时内有效，且只能使用一次。如非本人操作，请忽略此邮件。",
+	})
+}
 
-	return nil
+// VerifyUserEmail 使用验证令牌验证邮箱。
+//
+// 参数：
+//   - token：验证令牌
+//
+// 返回值：
+//   - error：如果令牌无效，则返回 ErrOneTimeTokenInvalid；如果邮箱已被其他用户验证，则返回 ErrEmailInUse；如果在验证过程中发生其他错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) VerifyUserEmail(token string) error {
+	// 使用令牌
+	oneTimeToken, err := service.userStore.ConsumeUserOneTimeToken(encryptors.HashToken(token), consts.ONE_TIME_TOKEN_PURPOSE_EMAIL_VERIFICATION)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrOneTimeTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	// 写入已验证的邮箱，并发验证同一邮箱时由唯一约束保证邮箱不重复
+	err = service.userStore.VerifyUserEmailByUID(oneTimeToken.UID, oneTimeToken.Target)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrOneTimeTokenInvalid
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrEmailInUse
+	}
+	return err
+}
+
+// RequestPasswordReset 请求重置密码，向已验证的邮箱发送重置令牌。
+//
+// 为避免泄露邮箱是否已注册，邮箱不存在或未验证时同样返回 nil。
+//
+// 参数：
+//   - email：邮箱
+//
+// 返回值：
+//   - error：如果在请求过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) RequestPasswordReset(email string) error {
+	// 校验邮箱
+	if !validers.IsValidEmail(email) {
+		return ErrEmailInvalid
+	}
+
+	// 获取用户信息
+	user, err := service.userStore.GetUserByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !user.EmailVerified {
+		return nil
+	}
+
+	// 发送重置令牌
+	token, err := service.issueOneTimeToken(uint64(user.ID), consts.ONE_TIME_TOKEN_PURPOSE_PASSWORD_RESET, email, consts.PASSWORD_RESET_TOKEN_EXPIRE)
+	if err != nil {
+		return err
+	}
+	return service.notifier.Send(&notifiers.Message{
+		To:      email,
+		Subject: "重置密码",
+		Body: "你正在重置 NekoBlog 账户 " + user.UserName + " 的密码。\n" +
+			"重置令牌：" + token + "\n" +
+			"该令牌 30 分钟内有效，且只能使用一次。如非本人操作，请忽略此邮件。",
+	})
+}
+
+// ResetPassword 使用重置令牌重置密码。
+//
+// 重置成功后撤销该用户的全部会话。
+//
+// 参数：
+//   - token：重置令牌
+//   - newPassword：新密码
+//
+// 返回值：
+//   - error：如果在重置过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) ResetPassword(token string, newPassword string) error {
+	// 校验新密码
+	if !validers.IsValidPassword(newPassword) {
+		return ErrPasswordInvalid
+	}
+
+	// 使用令牌
+	oneTimeToken, err := service.userStore.ConsumeUserOneTimeToken(encryptors.HashToken(token), consts.ONE_TIME_TOKEN_PURPOSE_PASSWORD_RESET)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrOneTimeTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	// 生成新的哈希密码
+	hashedNewPassword, err := encryptors.HashPassword(newPassword, service.passwordHashParams)
+	if err != nil {
+		return err
+	}
+
+	// 更新密码并撤销全部会话
+	return service.userStore.UpdateUserPasswordByUID(oneTimeToken.UID, hashedNewPassword, "")
+}
+
+// issueOneTimeToken 签发一次性令牌。
+//
+// 参数：
+//   - uid：用户ID
+//   - purpose：令牌用途
+//   - target：令牌绑定的目标
+//   - expire：令牌有效期
+//
+// 返回值：
+//   - string：一次性令牌明文，仅用于发送给用户。
+//   - error：如果在签发过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (service *UserService) issueOneTimeToken(uid uint64, purpose string, target string, expire time.Duration) (string, error) {
+	token, err := generators.GenerateSecretToken(consts.ONE_TIME_TOKEN_BYTES)
+	if err != nil {
+		return "", err
+	}
+
+	err = service.userStore.CreateUserOneTimeToken(&models.UserOneTimeToken{
+		UID:        uid,
+		Purpose:    purpose,
+		Target:     target,
+		TokenHash:  encryptors.HashToken(token),
+		ExpireTime: time.Now().Add(expire),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
 }
 
 // UpdateUserInfo 更新用户信息。
@@ -0,0 +1,128 @@
+/*
+Package storages - NekoBlog backend server media storage backends.
+This file is for local filesystem storage.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package storages
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage 本地文件系统存储后端，对象由服务器以静态资源的形式提供访问
+type LocalStorage struct {
+	root    string // 存储根目录
+	baseURL string // 静态资源访问路径前缀
+}
+
+// NewLocalStorage 创建一个新的本地文件系统存储后端。
+//
+// 参数：
+//   - root：存储根目录，为空时使用 ./public
+//   - baseURL：静态资源访问路径前缀，为空时使用 /resources
+//
+// 返回值：
+//   - *LocalStorage：新的本地文件系统存储后端。
+func NewLocalStorage(root, baseURL string) *LocalStorage {
+	if root == "" {
+		root = "./public"
+	}
+	if baseURL == "" {
+		baseURL = "/resources"
+	}
+	return &LocalStorage{
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Root 获取存储根目录。
+//
+// 返回值：
+//   - string：存储根目录。
+func (storage *LocalStorage) Root() string {
+	return storage.root
+}
+
+// BaseURL 获取静态资源访问路径前缀。
+//
+// 返回值：
+//   - string：静态资源访问路径前缀。
+func (storage *LocalStorage) BaseURL() string {
+	return storage.baseURL
+}
+
+// Put 将对象写入文件，自动创建所需的目录。
+//
+// 参数：
+//   - key：对象键
+//   - data：对象数据
+//   - contentType：对象的 MIME 类型，本地存储不使用
+//
+// 返回值：
+//   - error：如果在写入过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (storage *LocalStorage) Put(key string, data []byte, contentType string) error {
+	filePath := storage.filePath(key)
+	err := os.MkdirAll(filepath.Dir(filePath), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0o644)
+}
+
+// Get 读取对象文件。
+//
+// 参数：
+//   - key：对象键
+//
+// 返回值：
+//   - []byte：对象数据。
+//   - error：文件不存在时返回 ErrObjectNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (storage *LocalStorage) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(storage.filePath(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	return data, err
+}
+
+// Delete 删除对象文件，文件不存在时视为删除成功。
+//
+// 参数：
+//   - key：对象键
+//
+// 返回值：
+//   - error：如果在删除过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (storage *LocalStorage) Delete(key string) error {
+	err := os.Remove(storage.filePath(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// URL 获取对象的静态资源访问路径。
+//
+// 参数：
+//   - key：对象键
+//
+// 返回值：
+//   - string：对象的访问路径。
+func (storage *LocalStorage) URL(key string) string {
+	return storage.baseURL + "/" + key
+}
+
+// filePath 获取对象键对应的文件路径，键中的 ".." 等路径片段会被清理，保证路径不会越出根目录。
+//
+// 参数：
+//   - key：对象键
+//
+// 返回值：
+//   - string：文件路径。
+func (storage *LocalStorage) filePath(key string) string {
+	return filepath.Join(storage.root, filepath.FromSlash(filepath.Clean("/"+key)))
+}
@@ -0,0 +1,330 @@
+/*
+Package storages - NekoBlog backend server media storage backends.
+This file is for S3 compatible object storage.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package storages
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Storage S3 兼容对象存储后端，支持 AWS S3、MinIO 等服务
+//
+// 请求使用 AWS Signature Version 4 签名，对象需通过存储桶策略或 CDN 提供公开读取。
+type S3Storage struct {
+	endpoint        *url.URL     // 服务地址
+	region          string       // 区域
+	bucket          string       // 存储桶名称
+	accessKeyID     string       // 访问密钥ID
+	secretAccessKey string       // 访问密钥
+	usePathStyle    bool         // 是否使用路径形式访问存储桶，MinIO 等自建服务通常需要开启
+	publicURL       string       // 对象的公开访问地址前缀
+	client          *http.Client // HTTP 客户端
+}
+
+// NewS3Storage 创建一个新的 S3 兼容对象存储后端。
+//
+// 参数：
+//   - endpoint：服务地址，如 https://s3.us-east-1.amazonaws.com 或 http://localhost:9000
+//   - region：区域，为空时使用 us-east-1
+//   - bucket：存储桶名称
+//   - accessKeyID：访问密钥ID
+//   - secretAccessKey：访问密钥
+//   - usePathStyle：是否使用路径形式访问存储桶
+//   - publicURL：对象的公开访问地址前缀，为空时使用存储桶地址
+//
+// 返回值：
+//   - *S3Storage：新的 S3 兼容对象存储后端。
+//   - error：如果配置不合法，则返回相应的错误信息，否则返回nil。
+func NewS3Storage(
+	endpoint string,
+	region string,
+	bucket string,
+	accessKeyID string,
+	secretAccessKey string,
+	usePathStyle bool,
+	publicURL string,
+) (*S3Storage, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpointURL.Scheme != "http" && endpointURL.Scheme != "https" || endpointURL.Host == "" {
+		return nil, errors.New("s3 endpoint must be an absolute http or https url")
+	}
+	if bucket == "" {
+		return nil, errors.New("s3 bucket is required")
+	}
+	if accessKeyID == "" || secretAccessKey == "" {
+		return nil, errors.New("s3 access key id and secret access key are required")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	storage := &S3Storage{
+		endpoint:        endpointURL,
+		region:          region,
+		bucket:          bucket,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		usePathStyle:    usePathStyle,
+		publicURL:       strings.TrimSuffix(publicURL, "/"),
+		client:          &http.Client{Timeout: 30 * time.Second},
+	}
+	if storage.publicURL == "" {
+		storage.publicURL = storage.bucketURL().String()
+	}
+	return storage, nil
+}
+
+// Put 上传对象。
+//
+// 参数：
+//   - key：对象键
+//   - data：对象数据
+//   - contentType：对象的 MIME 类型
+//
+// 返回值：
+//   - error：如果在上传过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (storage *S3Storage) Put(key string, data []byte, contentType string) error {
+	resp, err := storage.do(http.MethodPut, key, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newS3Error(resp)
+	}
+	return nil
+}
+
+// Get 下载对象。
+//
+// 参数：
+//   - key：对象键
+//
+// 返回值：
+//   - []byte：对象数据。
+//   - error：对象不存在时返回 ErrObjectNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (storage *S3Storage) Get(key string) ([]byte, error) {
+	resp, err := storage.do(http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, ErrObjectNotFound
+	default:
+		return nil, newS3Error(resp)
+	}
+}
+
+// Delete 删除对象，S3 在对象不存在时同样返回成功。
+//
+// 参数：
+//   - key：对象键
+//
+// 返回值：
+//   - error：如果在删除过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (storage *S3Storage) Delete(key string) error {
+	resp, err := storage.do(http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return newS3Error(resp)
+	}
+	return nil
+}
+
+// URL 获取对象的公开访问地址。
+//
+// 参数：
+//   - key：对象键
+//
+// 返回值：
+//   - string：对象的访问地址。
+func (storage *S3Storage) URL(key string) string {
+	return storage.publicURL + "/" + escapeS3Path(key)
+}
+
+// bucketURL 获取存储桶地址。
+//
+// 返回值：
+//   - *url.URL：路径形式为 endpoint/bucket，否则为 bucket.endpoint。
+func (storage *S3Storage) bucketURL() *url.URL {
+	bucketURL := *storage.endpoint
+	basePath := strings.TrimSuffix(bucketURL.Path, "/")
+	if storage.usePathStyle {
+		bucketURL.Path = basePath + "/" + storage.bucket
+	} else {
+		bucketURL.Host = storage.bucket + "." + bucketURL.Host
+		bucketURL.Path = basePath
+	}
+	bucketURL.RawPath = ""
+	return &bucketURL
+}
+
+// do 构造并发送签名后的对象请求。
+//
+// 参数：
+//   - method：请求方法
+//   - key：对象键
+//   - body：请求体，无请求体时为 nil
+//   - contentType：请求体的 MIME 类型，无请求体时为空
+//
+// 返回值：
+//   - *http.Response：响应。
+//   - error：如果在发送请求过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (storage *S3Storage) do(method, key string, body []byte, contentType string) (*http.Response, error) {
+	objectURL := storage.bucketURL()
+	objectURL.Path += "/" + key
+	objectURL.RawPath = escapeS3Path(objectURL.Path)
+
+	req, err := http.NewRequest(method, objectURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	storage.sign(req, body, time.Now().UTC())
+
+	return storage.client.Do(req)
+}
+
+// sign 使用 AWS Signature Version 4 为请求签名，签名覆盖 Host 与请求中已设置的全部头部。
+//
+// 参数：
+//   - req：待签名的请求
+//   - body：请求体
+//   - now：签名时间
+func (storage *S3Storage) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256.Sum256(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	// 规范头部
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name)
+		canonicalHeaders.WriteRune(':')
+		canonicalHeaders.WriteString(headers[name])
+		canonicalHeaders.WriteRune('\n')
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// 规范请求
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	// 待签字符串
+	scope := date + "/" + storage.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	// 派生签名密钥并计算签名
+	signingKey := hmacSHA256([]byte("AWS4"+storage.secretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, storage.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		storage.accessKeyID,
+		scope,
+		signedHeaders,
+		signature,
+	))
+}
+
+// hmacSHA256 计算 HMAC-SHA256。
+//
+// 参数：
+//   - key：密钥
+//   - data：数据
+//
+// 返回值：
+//   - []byte：消息认证码。
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escapeS3Path 按 S3 的规则编码路径，除非保留字符与 "/" 外的字节均进行百分号编码。
+//
+// 参数：
+//   - path：未编码的路径
+//
+// 返回值：
+//   - string：编码后的路径。
+func escapeS3Path(path string) string {
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
+
+// newS3Error 根据非预期的响应创建错误。
+//
+// 参数：
+//   - resp：响应
+//
+// 返回值：
+//   - error：包含状态码与响应内容的错误。
+func newS3Error(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,143 @@
+/*
+Package storages - NekoBlog backend server media storage backends.
+This file is for media storage interface and factory.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package storages
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/configs"
+)
+
+// ErrObjectNotFound 对象不存在
+var ErrObjectNotFound = errors.New("object not found")
+
+const (
+	avatarPrefix    = "avatars" // 头像对象键前缀
+	postImagePrefix = "images"  // 博文图片对象键前缀
+)
+
+// MediaStorage 媒体文件存储后端
+//
+// 对象以 "avatars/<文件名>"、"images/<文件名>" 形式的键存储，
+// 数据库中仅保存文件名，由 AvatarKey 与 PostImageKey 转换为对象键。
+type MediaStorage interface {
+	// Put 写入对象，已存在时覆盖。
+	//
+	// 参数：
+	//   - key：对象键
+	//   - data：对象数据
+	//   - contentType：对象的 MIME 类型
+	//
+	// 返回值：
+	//   - error：如果在写入过程中发生错误，则返回相应的错误信息，否则返回nil。
+	Put(key string, data []byte, contentType string) error
+
+	// Get 读取对象。
+	//
+	// 参数：
+	//   - key：对象键
+	//
+	// 返回值：
+	//   - []byte：对象数据。
+	//   - error：对象不存在时返回 ErrObjectNotFound，其他错误返回相应的错误信息，否则返回nil。
+	Get(key string) ([]byte, error)
+
+	// Delete 删除对象，对象不存在时视为删除成功。
+	//
+	// 参数：
+	//   - key：对象键
+	//
+	// 返回值：
+	//   - error：如果在删除过程中发生错误，则返回相应的错误信息，否则返回nil。
+	Delete(key string) error
+
+	// URL 获取对象的公开访问地址。
+	//
+	// 参数：
+	//   - key：对象键
+	//
+	// 返回值：
+	//   - string：对象的访问地址。
+	URL(key string) string
+}
+
+// AvatarKey 获取头像文件对应的对象键。
+//
+// 参数：
+//   - fileName：头像文件名
+//
+// 返回值：
+//   - string：对象键。
+func AvatarKey(fileName string) string {
+	return path.Join(avatarPrefix, fileName)
+}
+
+// PostImageKey 获取博文图片文件对应的对象键。
+//
+// 参数：
+//   - fileName：图片文件名
+//
+// 返回值：
+//   - string：对象键。
+func PostImageKey(fileName string) string {
+	return path.Join(postImagePrefix, fileName)
+}
+
+// EnsureObject 确保对象存在，不存在时从本地文件上传，用于初始化默认头像等内置资源。
+//
+// 参数：
+//   - storage：媒体文件存储
+//   - key：对象键
+//   - filePath：本地文件路径
+//   - contentType：对象的 MIME 类型
+//
+// 返回值：
+//   - error：如果在检查或上传过程中发生错误，则返回相应的错误信息，否则返回nil。
+func EnsureObject(storage MediaStorage, key, filePath, contentType string) error {
+	_, err := storage.Get(key)
+	if !errors.Is(err, ErrObjectNotFound) {
+		return err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	return storage.Put(key, data, contentType)
+}
+
+// NewMediaStorage 根据配置文件创建媒体文件存储后端。
+//
+// 参数：
+//   - cfg：配置文件对象
+//
+// 返回值：
+//   - MediaStorage：媒体文件存储后端。
+//   - error：如果配置不合法，则返回相应的错误信息，否则返回nil。
+func NewMediaStorage(cfg *configs.Config) (MediaStorage, error) {
+	switch cfg.Storage.Type {
+	case "", "local":
+		localConfig := cfg.Storage.Local
+		return NewLocalStorage(localConfig.Root, localConfig.BaseURL), nil
+	case "s3":
+		s3Config := cfg.Storage.S3
+		return NewS3Storage(
+			s3Config.Endpoint,
+			s3Config.Region,
+			s3Config.Bucket,
+			s3Config.AccessKeyID,
+			s3Config.SecretAccessKey,
+			s3Config.UsePathStyle,
+			s3Config.PublicURL,
+		)
+	default:
+		return nil, fmt.Errorf("unsupported storage type: %s", cfg.Storage.Type)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Package stores - NekoBlog backend server data access objects.
+This file is for atomic array column operations.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package stores
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// appendToArrayColumn 原子地将值加入记录的数组列，若已存在则不会重复加入。
+//
+// 参数：
+//   - db：数据库连接
+//   - model：模型
+//   - id：记录ID
+//   - column：数组列名
+//   - value：要加入的值
+//
+// 返回值：
+//   - error：记录不存在时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func appendToArrayColumn(db *gorm.DB, model interface{}, id uint64, column string, value uint64) error {
+	return updateArrayColumns(db, model, id, map[string]interface{}{
+		column: arrayAppendExpr(column, value),
+	})
+}
+
+// removeFromArrayColumn 原子地将值从记录的数组列中移除。
+//
+// 参数：
+//   - db：数据库连接
+//   - model：模型
+//   - id：记录ID
+//   - column：数组列名
+//   - value：要移除的值
+//
+// 返回值：
+//   - error：记录不存在时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func removeFromArrayColumn(db *gorm.DB, model interface{}, id uint64, column string, value uint64) error {
+	return updateArrayColumns(db, model, id, map[string]interface{}{
+		column: arrayRemoveExpr(column, value),
+	})
+}
+
+// updateArrayColumns 在同一条语句中原子地更新记录的多个数组列。
+//
+// 参数：
+//   - db：数据库连接
+//   - model：模型
+//   - id：记录ID
+//   - updates：列名与更新表达式
+//
+// 返回值：
+//   - error：记录不存在时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func updateArrayColumns(db *gorm.DB, model interface{}, id uint64, updates map[string]interface{}) error {
+	result := db.Model(model).Where("id = ?", id).UpdateColumns(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// arrayAppendExpr 返回将值加入数组列的表达式，若已存在则不会重复加入。
+//
+// 参数：
+//   - column：数组列名
+//   - value：要加入的值
+//
+// 返回值：
+//   - clause.Expr：更新表达式。
+func arrayAppendExpr(column string, value uint64) clause.Expr {
+	return gorm.Expr("array_append(array_remove(?, ?), ?)", clause.Column{Name: column}, value, value)
+}
+
+// arrayRemoveExpr 返回将值从数组列中移除的表达式。
+//
+// 参数：
+//   - column：数组列名
+//   - value：要移除的值
+//
+// 返回值：
+//   - clause.Expr：更新表达式。
+func arrayRemoveExpr(column string, value uint64) clause.Expr {
+	return gorm.Expr("array_remove(?, ?)", clause.Column{Name: column}, value)
+}
@@ -9,9 +9,12 @@ package stores
 
 import (
 	"errors"
+	"time"
 
 	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Comment 评论信息数据库
@@ -26,14 +29,14 @@ func (factory *Factory) NewCommentStore() *CommentStore {
 	return &CommentStore{factory.db}
 }
 
-// NewCommentStore 存储comment
+// CreateComment 存储comment
 //
-// 参数 ：- uid：用户id，- username: 用户名，- postID: 博文id，- content: 博文内容
+// 参数 ：- uid：用户id，- username: 用户名，- postID: 博文id，- parent: 回复的评论，不是回复时为nil，- content: 博文内容，- isPublic: 是否公开
 //
 // 返回：
 //
 //	-error 正确返回nil
-func (store *CommentStore) CreateComment(uid uint64, username string, postID uint64, content string) error {
+func (store *CommentStore) CreateComment(uid uint64, username string, postID uint64, parent *models.CommentInfo, content string, isPublic bool) error {
 	newComment := &models.CommentInfo{
 		PostID:   postID,
 		Username: username,
@@ -41,14 +44,31 @@ func (store *CommentStore) CreateComment(uid uint64, username string, postID uin
 		UID:      uid,
 		Like:     nil,
 		Dislike:  nil,
-		IsPublic: true,
+		IsPublic: isPublic,
 	}
 
-	result := store.db.Create(newComment)
-	if result.Error != nil {
-		return result.Error
+	// 不是回复时直接创建评论
+	if parent == nil {
+		return store.db.Create(newComment).Error
 	}
-	return nil
+
+	// 回复评论时记录所属楼层
+	parentID := uint64(parent.ID)
+	newComment.ParentID = &parentID
+	if parent.RootID != nil {
+		newComment.RootID = parent.RootID
+	} else {
+		newComment.RootID = &parentID
+	}
+
+	// 创建回复并更新回复数
+	return store.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(newComment).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.CommentInfo{}).Where("id = ?", parentID).
+			UpdateColumn("reply_count", gorm.Expr("reply_count + 1")).Error
+	})
 }
 
 //	ValidateCommentExistence 判断评论是否存在
@@ -73,52 +93,202 @@ func (store *CommentStore) ValidateCommentExistence(commentID uint64) (bool, err
 
 // UpdateComment 修改评论
 //
+// 仅更新内容列，避免覆盖并发写入的点赞、点踩与回复数。
+//
 //	参数：
 //	- commentID: 评论ID
 //	- content: 修改内容
 //
 // 返回值：
-//   - error：如果评论存在返回true，不存在判断具体的错误类型返回false
+//   - error：评论不存在时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
 func (store *CommentStore) UpdateComment(commentID uint64, content string) error {
-	commentInfo := new(models.CommentInfo)
-	result := store.db.Where("id = ?", commentID).First(commentInfo)
+	result := store.db.Model(&models.CommentInfo{}).Where("id = ?", commentID).UpdateColumn("content", content)
 	if result.Error != nil {
 		return result.Error
 	}
-
-	commentInfo.Content = content
-	result = store.db.Save(commentInfo)
-	if result.Error != nil {
-		return result.Error
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
 	}
 	return nil
 }
 
-// DeleteComment 删除评论
+// DeleteComment 将评论及其下的全部回复移入回收站
+//
+// 评论仅被软删除，保留期内可以恢复，超过保留期后由定时任务彻底清除。
+// 同一次删除的评论与回复使用相同的删除时间，以便一并恢复或清除。
 //
 // 参数：
 //   - commentID：评论ID
+//   - deletedBy：执行删除的用户ID
 //
 // 返回值：
 //   - error：返回删除处理的成功与否
-func (store *CommentStore) DeleteComment(commentID uint64) error {
-	return store.db.Where("id = ?", commentID).Unscoped().Delete(&models.CommentInfo{}).Error
+func (store *CommentStore) DeleteComment(commentID uint64, deletedBy uint64) error {
+	return store.db.Transaction(func(tx *gorm.DB) error {
+		comment := new(models.CommentInfo)
+		if err := tx.Where("id = ?", commentID).First(comment).Error; err != nil {
+			return err
+		}
+
+		// 此前已删除的回复保留原删除时间，不随本次删除恢复
+		result := tx.Model(&models.CommentInfo{}).Where("id IN (?)", commentSubtreeExpr(commentID)).UpdateColumns(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": deletedBy,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// 更新所回复评论的回复数
+		if comment.ParentID == nil {
+			return nil
+		}
+		return tx.Model(&models.CommentInfo{}).Where("id = ? AND reply_count > 0", *comment.ParentID).
+			UpdateColumn("reply_count", gorm.Expr("reply_count - 1")).Error
+	})
 }
 
-// GetCommentList 获取评论列表
+// GetDeletedCommentInfo 获取回收站中的评论信息
+//
+// 参数：
+//   - commentID：评论ID
 //
 // 返回值：
-//   - 成功则返回评论列表
+//   - models.CommentInfo：评论信息
+//   - error：评论不存在或未被删除时返回 gorm.ErrRecordNotFound
+func (store *CommentStore) GetDeletedCommentInfo(commentID uint64) (models.CommentInfo, error) {
+	comment := models.CommentInfo{}
+	result := store.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", commentID).First(&comment)
+	return comment, result.Error
+}
+
+// GetTrashedCommentList 按发布时间倒序分页获取用户自行删除的评论列表
+//
+// 随博文一同删除的评论只能通过恢复博文恢复，因此不包含所属博文已被删除的评论。
+// 所回复的评论同样已删除的回复随该评论一并恢复，不单独列出。
+//
+// 参数：
+//   - uid：用户ID
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - []models.CommentInfo：评论信息切片，最多包含 limit+1 条记录
+//   - error：正确返回nil
+func (store *CommentStore) GetTrashedCommentList(uid uint64, cursor *types.Cursor, limit int) ([]models.CommentInfo, error) {
+	var comments []models.CommentInfo
+	result := store.db.Unscoped().
+		Where("uid = ? AND deleted_by = ? AND deleted_at IS NOT NULL", uid, uid).
+		Where("post_id IN (SELECT post_infos.id FROM post_infos WHERE post_infos.deleted_at IS NULL)").
+		Where("parent_id IS NULL OR parent_id IN (?)", store.db.Model(&models.CommentInfo{}).Select("id")).
+		Scopes(paginateByCursor(cursor, limit)).
+		Find(&comments)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return comments, nil
+}
+
+// RestoreComment 从回收站中恢复评论，以及与其同时删除的回复
+//
+// 参数：
+//   - commentID：评论ID
+//
+// 返回值：
+//   - error：评论不存在或未被删除时返回 gorm.ErrRecordNotFound
+func (store *CommentStore) RestoreComment(commentID uint64) error {
+	return store.db.Transaction(func(tx *gorm.DB) error {
+		comment := new(models.CommentInfo)
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", commentID).First(comment).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Model(&models.CommentInfo{}).
+			Where("id IN (?) AND deleted_at = ?", commentSubtreeExpr(commentID), comment.DeletedAt.Time).
+			UpdateColumns(map[string]interface{}{
+				"deleted_at": nil,
+				"deleted_by": nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// 恢复所回复评论的回复数
+		if comment.ParentID == nil {
+			return nil
+		}
+		return tx.Model(&models.CommentInfo{}).Where("id = ?", *comment.ParentID).
+			UpdateColumn("reply_count", gorm.Expr("reply_count + 1")).Error
+	})
+}
+
+// commentSubtreeExpr 获取评论及其下全部回复ID的子查询，包含已删除的回复。
+//
+// 参数：
+//   - commentID：评论ID
+//
+// 返回值：
+//   - clause.Expr：子查询表达式。
+func commentSubtreeExpr(commentID uint64) clause.Expr {
+	return gorm.Expr(
+		"WITH RECURSIVE subtree AS ("+
+			"SELECT id FROM comment_infos WHERE id = ? "+
+			"UNION ALL SELECT comment_infos.id FROM comment_infos JOIN subtree ON comment_infos.parent_id = subtree.id"+
+			") SELECT id FROM subtree",
+		commentID,
+	)
+}
+
+// GetCommentList 按发布时间倒序分页获取博文下访问者可见的顶层评论列表
+//
+// 参数：
+//   - postID：博文ID
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - 成功则返回评论列表，最多包含 limit+1 条记录
 //   - 失败返回nil
-func (store *CommentStore) GetCommentList() ([]models.CommentInfo, error) {
+func (store *CommentStore) GetCommentList(postID uint64, viewerUID uint64, cursor *types.Cursor, limit int) ([]models.CommentInfo, error) {
 	var userComments []models.CommentInfo
-	result := store.db.Find(&userComments)
+	result := store.db.Where("post_id = ? AND parent_id IS NULL", postID).
+		Scopes(commentVisibleTo(viewerUID), paginateByCursor(cursor, limit)).
+		Find(&userComments)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return userComments, nil
 }
 
+// GetReplyList 按发布时间倒序分页获取评论下访问者可见的回复列表
+//
+// 参数：
+//   - commentID：评论ID
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - 成功则返回回复列表，最多包含 limit+1 条记录
+//   - 失败返回nil
+func (store *CommentStore) GetReplyList(commentID uint64, viewerUID uint64, cursor *types.Cursor, limit int) ([]models.CommentInfo, error) {
+	var replies []models.CommentInfo
+	result := store.db.Where("parent_id = ?", commentID).
+		Scopes(commentVisibleTo(viewerUID), paginateByCursor(cursor, limit)).
+		Find(&replies)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return replies, nil
+}
+
 // GetCommentInfo 获取评论信息
 //
 // 参数：
@@ -132,3 +302,63 @@ func (store *CommentStore) GetCommentInfo(commentID uint64) (models.CommentInfo,
 	result := store.db.Where("id = ?", commentID).First(&comment)
 	return comment, result.Error
 }
+
+// GetVisibleCommentInfo 获取访问者可见的评论信息
+//
+// 参数：
+//   - commentID：评论ID
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//
+// 返回值：
+//   - models.CommentInfo：成功返回评论信息
+//   - error：评论不存在或不可见时返回 gorm.ErrRecordNotFound
+func (store *CommentStore) GetVisibleCommentInfo(commentID uint64, viewerUID uint64) (models.CommentInfo, error) {
+	comment := models.CommentInfo{}
+	result := store.db.Scopes(commentVisibleTo(viewerUID)).Where("id = ?", commentID).First(&comment)
+	return comment, result.Error
+}
+
+// LikeComment 点赞评论，同时取消该用户的点踩。
+//
+// 参数：
+//   - commentID：评论ID
+//   - uid：用户ID
+//
+// 返回值：
+//   - error：评论不存在或不可见时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (store *CommentStore) LikeComment(commentID uint64, uid uint64) error {
+	return updateArrayColumns(store.db.Scopes(commentVisibleTo(uid)), &models.CommentInfo{}, commentID, map[string]interface{}{
+		"like":    arrayAppendExpr("like", uid),
+		"dislike": arrayRemoveExpr("dislike", uid),
+	})
+}
+
+// DislikeComment 点踩评论，同时取消该用户的点赞。
+//
+// 参数：
+//   - commentID：评论ID
+//   - uid：用户ID
+//
+// 返回值：
+//   - error：评论不存在或不可见时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (store *CommentStore) DislikeComment(commentID uint64, uid uint64) error {
+	return updateArrayColumns(store.db.Scopes(commentVisibleTo(uid)), &models.CommentInfo{}, commentID, map[string]interface{}{
+		"like":    arrayRemoveExpr("like", uid),
+		"dislike": arrayAppendExpr("dislike", uid),
+	})
+}
+
+// ClearCommentReaction 清除用户对评论的点赞或点踩。
+//
+// 参数：
+//   - commentID：评论ID
+//   - uid：用户ID
+//
+// 返回值：
+//   - error：评论不存在或不可见时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (store *CommentStore) ClearCommentReaction(commentID uint64, uid uint64) error {
+	return updateArrayColumns(store.db.Scopes(commentVisibleTo(uid)), &models.CommentInfo{}, commentID, map[string]interface{}{
+		"like":    arrayRemoveExpr("like", uid),
+		"dislike": arrayRemoveExpr("dislike", uid),
+	})
+}
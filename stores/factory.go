@@ -6,12 +6,17 @@ Copyright (c) [2024], Author(s):
 */
 package stores
 
-import "gorm.io/gorm"
+import (
+	"gorm.io/gorm"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/storages"
+)
 
 type Factory struct {
-	db *gorm.DB
+	db           *gorm.DB
+	mediaStorage storages.MediaStorage
 }
 
-func NewFactory(db *gorm.DB) *Factory {
-	return &Factory{db: db}
+func NewFactory(db *gorm.DB, mediaStorage storages.MediaStorage) *Factory {
+	return &Factory{db: db, mediaStorage: mediaStorage}
 }
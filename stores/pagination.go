@@ -0,0 +1,32 @@
+/*
+Package stores - NekoBlog backend server data access objects.
+This file is for pagination scopes.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package stores
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+)
+
+// paginateByCursor 按创建时间与ID倒序进行游标分页。
+//
+// 为便于判断是否存在下一页，会多查询一条记录。
+//
+// 参数：
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - func(*gorm.DB) *gorm.DB：gorm 查询作用域。
+func paginateByCursor(cursor *types.Cursor, limit int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if cursor != nil {
+			db = db.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+		return db.Order("created_at desc, id desc").Limit(limit + 1)
+	}
+}
@@ -9,22 +9,24 @@ Copyright (c) [2024], Author(s):
 package stores
 
 import (
-	"bytes"
 	"errors"
-	"io"
-	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
 	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/storages"
 	"github.com/Kirisakiii/neko-micro-blog-backend/types"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // PostStore 博文信息数据库
 type PostStore struct {
-	db *gorm.DB
+	db           *gorm.DB
+	mediaStorage storages.MediaStorage
 }
 
 // NewPostStore 是一个工厂方法，用于创建 PostStore 的新实例。
@@ -35,17 +37,54 @@ type PostStore struct {
 // 返回值
 // 它初始化并返回一个 PostStore，并关联了相应的 gorm.DB。
 func (factory *Factory) NewPostStore() *PostStore {
-	return &PostStore{factory.db}
+	return &PostStore{factory.db, factory.mediaStorage}
 }
 
-// GetPostLis 获取适用于用户查看的帖子信息列表。
+// GetPostList 按发布时间倒序分页获取访问者可见的博文列表。
+//
+// 参数：
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
 //
 // 返回值：
-// - []models.UserPostInfo: 包含适用于用户查看的帖子信息的切片。
+// - []models.PostInfo: 博文信息切片，最多包含 limit+1 条记录。
 // - error: 在检索过程中遇到的任何错误，如果有的话。
-func (store *PostStore) GetPostList() ([]models.PostInfo, error) {
+func (store *PostStore) GetPostList(viewerUID uint64, cursor *types.Cursor, limit int) ([]models.PostInfo, error) {
+	var userPosts []models.PostInfo
+	if result := store.db.Scopes(postVisibleTo(viewerUID), paginateByCursor(cursor, limit)).Find(&userPosts); result.Error != nil {
+		return nil, result.Error
+	}
+	return userPosts, nil
+}
+
+// GetUserPostList 按发布时间倒序分页获取用户主页指定标签页下访问者可见的博文列表。
+//
+// 参数：
+//   - uid：用户ID
+//   - tab：标签页，取值见 consts.USER_POST_TAB_*
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - []models.PostInfo：博文信息切片，最多包含 limit+1 条记录。
+//   - error：如果在检索过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *PostStore) GetUserPostList(uid uint64, tab string, viewerUID uint64, cursor *types.Cursor, limit int) ([]models.PostInfo, error) {
+	db := store.db.Scopes(postVisibleTo(viewerUID), paginateByCursor(cursor, limit))
+	switch tab {
+	case consts.USER_POST_TAB_IMAGES:
+		db = db.Where("uid = ? AND cardinality(images) > 0", uid)
+	case consts.USER_POST_TAB_LIKES:
+		db = db.Where(`? = ANY("like")`, int64(uid))
+	case consts.USER_POST_TAB_FAVORITES:
+		db = db.Where("? = ANY(favorite)", int64(uid))
+	default:
+		db = db.Where("uid = ?", uid)
+	}
+
 	var userPosts []models.PostInfo
-	if result := store.db.Find(&userPosts); result.Error != nil {
+	if result := db.Find(&userPosts); result.Error != nil {
 		return nil, result.Error
 	}
 	return userPosts, nil
@@ -71,20 +110,35 @@ func (store *PostStore) ValidatePostExistence(postID uint64) (bool, error) {
 	return true, nil
 }
 
-// GetPostByUID 通过用户UID获取用户信息。
+// GetPostInfo 通过博文ID获取博文信息，不校验可见性。
 //
 // 参数：
-//   - uid：用户ID
+//   - postID：博文ID
 //
 // 返回值：
-//   - *models.PostInfo：如果找到了相应的用户信息，则返回该用户信息，否则返回nil。
-//   - error：如果在获取过程中发生错误，则返回相应的错误信息，否则返回nil。
+//   - models.PostInfo：博文信息。
+//   - error：博文不存在时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
 func (store *PostStore) GetPostInfo(postID uint64) (models.PostInfo, error) {
 	post := models.PostInfo{}
 	result := store.db.Where("id = ?", postID).First(&post)
 	return post, result.Error
 }
 
+// GetVisiblePostInfo 获取访问者可见的博文信息。
+//
+// 参数：
+//   - postID：博文ID
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//
+// 返回值：
+//   - models.PostInfo：博文信息。
+//   - error：博文不存在或不可见时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (store *PostStore) GetVisiblePostInfo(postID uint64, viewerUID uint64) (models.PostInfo, error) {
+	post := models.PostInfo{}
+	result := store.db.Scopes(postVisibleTo(viewerUID)).Where("id = ?", postID).First(&post)
+	return post, result.Error
+}
+
 // CreatePost 根据用户提交的帖子信息创建帖子。
 //
 // 参数：
@@ -96,30 +150,10 @@ func (store *PostStore) GetPostInfo(postID uint64) (models.PostInfo, error) {
 // 返回值：
 //   - error：如果在创建过程中发生错误，则返回相应的错误信息，否则返回nil。
 func (store *PostStore) CreatePost(uid uint64, ipAddr string, postReqData types.PostCreateBody, images [][]byte) (models.PostInfo, error) {
-	var imageFileNames []string
-	// 将图片写入文件系统
-	for _, image := range images {
-		for {
-			imageFileName := strings.ReplaceAll(uuid.New().String(), "-", "") + ".webp"
-			savePath := filepath.Join("./public/images", imageFileName)
-			_, err := os.Stat(savePath)
-			if os.IsExist(err) {
-				continue
-			}
-			imageFileNames = append(imageFileNames, imageFileName)
-
-			file, err := os.Create(savePath)
-			if err != nil {
-				return models.PostInfo{}, err
-			}
-			defer file.Close()
-
-			_, err = io.Copy(file, bytes.NewReader(image))
-			if err != nil {
-				return models.PostInfo{}, err
-			}
-			break
-		}
+	// 将图片写入媒体存储
+	imageFileNames, err := store.savePostImages(images)
+	if err != nil {
+		return models.PostInfo{}, err
 	}
 
 	// 将博文数据写入数据库
@@ -130,19 +164,367 @@ func (store *PostStore) CreatePost(uid uint64, ipAddr string, postReqData types.
 		Title:        postReqData.Title,
 		Content:      postReqData.Content,
 		Images:       imageFileNames,
-		IsPublic:     true,
+		IsPublic:     postReqData.Visibility == consts.VISIBILITY_PUBLIC,
+		Visibility:   postReqData.Visibility,
 	}
 	result := store.db.Create(&postInfo)
 	return postInfo, result.Error
 }
 
-// DeletePost 通过博文ID删除博文的存储方法
+// EditPost 编辑博文，并将编辑前的博文快照保存为修订记录。
+//
+// 参数：
+//   - postID：博文ID
+//   - editorUID：执行编辑的用户ID
+//   - reqBody：编辑请求体，未提供的字段保持不变
+//   - keptImages：保留的原有图片
+//   - newImages：新上传的图片，追加在保留的图片之后
+//
+// 返回值：
+//   - models.PostInfo：编辑后的博文。
+//   - error：博文不存在时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (store *PostStore) EditPost(postID uint64, editorUID uint64, reqBody types.PostEditBody, keptImages []string, newImages [][]byte) (models.PostInfo, error) {
+	// 将新图片写入媒体存储，被移除的旧图片仍被修订记录引用，因此保留文件
+	newImageFileNames, err := store.savePostImages(newImages)
+	if err != nil {
+		return models.PostInfo{}, err
+	}
+
+	var post models.PostInfo
+	err = store.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定博文，保证修订序号连续
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", postID).First(&post)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// 保存编辑前的快照
+		revision := models.PostRevision{
+			PostID:     postID,
+			Revision:   post.RevisionCount + 1,
+			EditorUID:  editorUID,
+			Title:      post.Title,
+			Content:    post.Content,
+			Images:     post.Images,
+			Visibility: post.Visibility,
+		}
+		if result = tx.Create(&revision); result.Error != nil {
+			return result.Error
+		}
+
+		// 更新博文
+		updates := map[string]interface{}{
+			"images":         pq.StringArray(append(keptImages, newImageFileNames...)),
+			"edited_at":      revision.CreatedAt,
+			"revision_count": revision.Revision,
+		}
+		if reqBody.Title != nil {
+			updates["title"] = *reqBody.Title
+		}
+		if reqBody.Content != nil {
+			updates["content"] = *reqBody.Content
+		}
+		if reqBody.Visibility != nil {
+			updates["visibility"] = *reqBody.Visibility
+			updates["is_public"] = *reqBody.Visibility == consts.VISIBILITY_PUBLIC
+		}
+		return tx.Model(&post).Updates(updates).Error
+	})
+	return post, err
+}
+
+// GetPostRevisions 按修订时间倒序分页获取博文的修订记录。
+//
+// 参数：
+//   - postID：博文ID
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - []models.PostRevision：修订记录切片，最多包含 limit+1 条记录。
+//   - error：如果在检索过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *PostStore) GetPostRevisions(postID uint64, cursor *types.Cursor, limit int) ([]models.PostRevision, error) {
+	var revisions []models.PostRevision
+	result := store.db.Where("post_id = ?", postID).
+		Scopes(paginateByCursor(cursor, limit)).
+		Find(&revisions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return revisions, nil
+}
+
+// savePostImages 将博文图片写入媒体存储。
+//
+// 参数：
+//   - images：处理后的图片数据
+//
+// 返回值：
+//   - []string：图片文件名。
+//   - error：如果在写入过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *PostStore) savePostImages(images [][]byte) ([]string, error) {
+	var imageFileNames []string
+	for _, image := range images {
+		imageFileName := strings.ReplaceAll(uuid.New().String(), "-", "") + ".webp"
+		err := store.mediaStorage.Put(storages.PostImageKey(imageFileName), image, "image/webp")
+		if err != nil {
+			return nil, err
+		}
+		imageFileNames = append(imageFileNames, imageFileName)
+	}
+	return imageFileNames, nil
+}
+
+// ForwardPost 转发博文，创建引用原博文的新博文并在原博文中记录转发者。
+//
+// 参数：
+//   - uid：转发者的用户ID
+//   - ipAddr：IP地址
+//   - parentPostID：被转发的博文ID
+//   - postReqData：转发时附带的标题与内容
+//
+// 返回值：
+//   - models.PostInfo：新创建的博文。
+//   - error：原博文不存在、已删除或未公开时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (store *PostStore) ForwardPost(uid uint64, ipAddr string, parentPostID uint64, postReqData types.PostForwardBody) (models.PostInfo, error) {
+	postInfo := models.PostInfo{
+		ParentPostID: &parentPostID,
+		UID:          uid,
+		IpAddrress:   &ipAddr,
+		Title:        postReqData.Title,
+		Content:      postReqData.Content,
+		IsPublic:     true,
+		Visibility:   consts.VISIBILITY_PUBLIC,
+	}
+
+	err := store.db.Transaction(func(tx *gorm.DB) error {
+		// 在原博文中记录转发者，仅公开博文可以被转发
+		err := appendToArrayColumn(
+			tx.Where("visibility = ?", consts.VISIBILITY_PUBLIC),
+			&models.PostInfo{}, parentPostID, "farward", uid,
+		)
+		if err != nil {
+			return err
+		}
+
+		// 创建转发博文
+		return tx.Create(&postInfo).Error
+	})
+	return postInfo, err
+}
+
+// DeletePost 通过博文ID将博文移入回收站的存储方法
+//
+// 博文仅被软删除，保留期内可以恢复，超过保留期后由定时任务彻底清除。
+// 博文下尚未删除的评论会在同一事务中以相同的删除时间一并移入回收站，
+// 转发博文被删除时同时撤回其在原博文中的转发记录。
 //
 // 参数：
 // - postID uint64：待删除博文的ID
+// - deletedBy uint64：执行删除的用户ID
+//
+// 返回值：
+// - error：博文不存在时返回 gorm.ErrRecordNotFound，其他错误返回相应错误信息；否则返回 nil
+func (store *PostStore) DeletePost(postID uint64, deletedBy uint64) error {
+	return store.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定博文
+		var post models.PostInfo
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", postID).First(&post)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// 软删除博文及其评论
+		tombstone := map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": deletedBy,
+		}
+		result = tx.Model(&models.PostInfo{}).Where("id = ?", postID).UpdateColumns(tombstone)
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Model(&models.CommentInfo{}).Where("post_id = ?", postID).UpdateColumns(tombstone)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// 撤回转发记录
+		return syncForwardRecord(tx, post)
+	})
+}
+
+// GetDeletedPostInfo 获取回收站中的博文信息。
+//
+// 参数：
+//   - postID：博文ID
+//
+// 返回值：
+//   - models.PostInfo：博文信息。
+//   - error：博文不存在或未被删除时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (store *PostStore) GetDeletedPostInfo(postID uint64) (models.PostInfo, error) {
+	post := models.PostInfo{}
+	result := store.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", postID).First(&post)
+	return post, result.Error
+}
+
+// GetTrashedPostList 按发布时间倒序分页获取用户自行删除的博文列表。
+//
+// 参数：
+//   - uid：用户ID
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - []models.PostInfo：博文信息切片，最多包含 limit+1 条记录。
+//   - error：如果在检索过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *PostStore) GetTrashedPostList(uid uint64, cursor *types.Cursor, limit int) ([]models.PostInfo, error) {
+	var posts []models.PostInfo
+	result := store.db.Unscoped().
+		Where("uid = ? AND deleted_by = ? AND deleted_at IS NOT NULL", uid, uid).
+		Scopes(paginateByCursor(cursor, limit)).
+		Find(&posts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return posts, nil
+}
+
+// RestorePost 从回收站中恢复博文。
+//
+// 与博文一同删除的评论会被一并恢复，单独删除的评论仍保留在回收站中。
+//
+// 参数：
+//   - postID：博文ID
+//
+// 返回值：
+//   - error：博文不存在或未被删除时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (store *PostStore) RestorePost(postID uint64) error {
+	return store.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定博文
+		var post models.PostInfo
+		result := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NOT NULL", postID).
+			First(&post)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// 恢复博文及与其一同删除的评论
+		restore := map[string]interface{}{
+			"deleted_at": nil,
+			"deleted_by": nil,
+		}
+		result = tx.Unscoped().Model(&models.PostInfo{}).Where("id = ?", postID).UpdateColumns(restore)
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Unscoped().Model(&models.CommentInfo{}).
+			Where("post_id = ? AND deleted_at = ?", postID, post.DeletedAt.Time).
+			UpdateColumns(restore)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// 恢复转发记录
+		return syncForwardRecord(tx, post)
+	})
+}
+
+// syncForwardRecord 根据转发者是否仍有未删除的转发博文，同步原博文中的转发记录。
+//
+// 参数：
+//   - tx：数据库事务
+//   - post：发生删除或恢复的博文
+//
+// 返回值：
+//   - error：如果在同步过程中发生错误，则返回相应的错误信息，否则返回nil。
+func syncForwardRecord(tx *gorm.DB, post models.PostInfo) error {
+	if post.ParentPostID == nil {
+		return nil
+	}
+
+	// 统计转发者对原博文未删除的转发数
+	var count int64
+	result := tx.Model(&models.PostInfo{}).
+		Where("parent_post_id = ? AND uid = ?", *post.ParentPostID, post.UID).
+		Count(&count)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// 原博文可能已被删除，仍需保持转发记录一致
+	var err error
+	if count > 0 {
+		err = appendToArrayColumn(tx.Unscoped(), &models.PostInfo{}, *post.ParentPostID, "farward", post.UID)
+	} else {
+		err = removeFromArrayColumn(tx.Unscoped(), &models.PostInfo{}, *post.ParentPostID, "farward", post.UID)
+	}
+	// 原博文已被彻底清除时无需同步
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+// UpdatePostVisibility 更新博文的可见性。
+//
+// 参数：
+//   - postID：博文ID
+//   - visibility：可见性
+//
+// 返回值：
+//   - error：如果在更新过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *PostStore) UpdatePostVisibility(postID uint64, visibility string) error {
+	return store.db.Model(&models.PostInfo{}).Where("id = ?", postID).Updates(map[string]interface{}{
+		"visibility": visibility,
+		"is_public":  visibility == consts.VISIBILITY_PUBLIC,
+	}).Error
+}
+
+// LikePost 点赞博文，重复点赞不会产生影响。
+//
+// 参数：
+//   - postID：博文ID
+//   - uid：用户ID
+//
+// 返回值：
+//   - error：博文不存在或不可见时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (store *PostStore) LikePost(postID uint64, uid uint64) error {
+	return appendToArrayColumn(store.db.Scopes(postVisibleTo(uid)), &models.PostInfo{}, postID, "like", uid)
+}
+
+// UnlikePost 取消点赞博文，未点赞时不会产生影响。
+//
+// 参数：
+//   - postID：博文ID
+//   - uid：用户ID
+//
+// 返回值：
+//   - error：博文不存在或不可见时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (store *PostStore) UnlikePost(postID uint64, uid uint64) error {
+	return removeFromArrayColumn(store.db.Scopes(postVisibleTo(uid)), &models.PostInfo{}, postID, "like", uid)
+}
+
+// FavoritePost 收藏博文，重复收藏不会产生影响。
+//
+// 参数：
+//   - postID：博文ID
+//   - uid：用户ID
+//
+// 返回值：
+//   - error：博文不存在或不可见时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (store *PostStore) FavoritePost(postID uint64, uid uint64) error {
+	return appendToArrayColumn(store.db.Scopes(postVisibleTo(uid)), &models.PostInfo{}, postID, "favorite", uid)
+}
+
+// UnfavoritePost 取消收藏博文，未收藏时不会产生影响。
+//
+// 参数：
+//   - postID：博文ID
+//   - uid：用户ID
 //
 // 返回值：
-// - error：如果发生错误，返回相应错误信息；否则返回 nil
-func (store *PostStore) DeletePost(postID uint64) error {
-	return store.db.Where("id = ?", postID).Unscoped().Delete(&models.PostInfo{}).Error
+//   - error：博文不存在或不可见时返回 gorm.ErrRecordNotFound，其他错误返回相应的错误信息，否则返回nil。
+func (store *PostStore) UnfavoritePost(postID uint64, uid uint64) error {
+	return removeFromArrayColumn(store.db.Scopes(postVisibleTo(uid)), &models.PostInfo{}, postID, "favorite", uid)
 }
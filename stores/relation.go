@@ -0,0 +1,204 @@
+/*
+Package stores - NekoBlog backend server data access objects.
+This file is for user relationship storage accessing.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package stores
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+)
+
+// RelationStore 用户关系存储
+type RelationStore struct {
+	db *gorm.DB
+}
+
+// NewRelationStore 返回一个新的用户关系存储实例。
+//
+// 返回值：
+//   - *RelationStore：新的用户关系存储实例。
+func (factory *Factory) NewRelationStore() *RelationStore {
+	return &RelationStore{factory.db}
+}
+
+// CreateUserFollow 创建关注关系。
+//
+// 任意一方屏蔽了另一方或已关注时不会创建记录。
+//
+// 参数：
+//   - followerUID：关注者用户ID
+//   - followeeUID：被关注者用户ID
+//
+// 返回值：
+//   - error：如果在创建过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *RelationStore) CreateUserFollow(followerUID uint64, followeeUID uint64) error {
+	return store.db.Exec(
+		"INSERT INTO user_follows (created_at, follower_uid, followee_uid) "+
+			"SELECT CAST(? AS timestamptz), CAST(? AS bigint), CAST(? AS bigint) WHERE NOT EXISTS ("+
+			"SELECT 1 FROM user_blocks WHERE (blocker_uid = ? AND blocked_uid = ?) OR (blocker_uid = ? AND blocked_uid = ?)"+
+			") ON CONFLICT (follower_uid, followee_uid) DO NOTHING",
+		time.Now(), followerUID, followeeUID,
+		followerUID, followeeUID, followeeUID, followerUID,
+	).Error
+}
+
+// DeleteUserFollow 删除关注关系。
+//
+// 参数：
+//   - followerUID：关注者用户ID
+//   - followeeUID：被关注者用户ID
+//
+// 返回值：
+//   - error：如果在删除过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *RelationStore) DeleteUserFollow(followerUID uint64, followeeUID uint64) error {
+	return store.db.
+		Where("follower_uid = ? AND followee_uid = ?", followerUID, followeeUID).
+		Delete(&models.UserFollow{}).Error
+}
+
+// GetFollowersByUID 分页获取用户的粉丝列表。
+//
+// 参数：
+//   - uid：用户ID
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - []models.UserFollow：关注关系列表，会多返回一条用于判断是否存在下一页。
+//   - error：如果在查询过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *RelationStore) GetFollowersByUID(uid uint64, cursor *types.Cursor, limit int) ([]models.UserFollow, error) {
+	var follows []models.UserFollow
+	result := store.db.Where("followee_uid = ?", uid).
+		Scopes(paginateByCursor(cursor, limit)).
+		Find(&follows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return follows, nil
+}
+
+// GetFollowingByUID 分页获取用户的关注列表。
+//
+// 参数：
+//   - uid：用户ID
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - []models.UserFollow：关注关系列表，会多返回一条用于判断是否存在下一页。
+//   - error：如果在查询过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *RelationStore) GetFollowingByUID(uid uint64, cursor *types.Cursor, limit int) ([]models.UserFollow, error) {
+	var follows []models.UserFollow
+	result := store.db.Where("follower_uid = ?", uid).
+		Scopes(paginateByCursor(cursor, limit)).
+		Find(&follows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return follows, nil
+}
+
+// GetUserRelationStats 获取用户的关系统计。
+//
+// 参数：
+//   - uid：用户ID
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//
+// 返回值：
+//   - *types.UserRelationStats：用户关系统计。
+//   - error：如果在查询过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *RelationStore) GetUserRelationStats(uid uint64, viewerUID uint64) (*types.UserRelationStats, error) {
+	stats := new(types.UserRelationStats)
+
+	result := store.db.Model(&models.UserFollow{}).Where("followee_uid = ?", uid).Count(&stats.FollowerCount)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	result = store.db.Model(&models.UserFollow{}).Where("follower_uid = ?", uid).Count(&stats.FollowingCount)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	// 匿名访问或查看自己时无需查询关注状态
+	if viewerUID == 0 || viewerUID == uid {
+		return stats, nil
+	}
+	var count int64
+	result = store.db.Model(&models.UserFollow{}).
+		Where("follower_uid = ? AND followee_uid = ?", viewerUID, uid).
+		Count(&count)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	stats.IsFollowing = count > 0
+
+	return stats, nil
+}
+
+// IsBlockedBetween 检查两个用户之间是否存在屏蔽关系，不区分屏蔽方向。
+//
+// 参数：
+//   - uid：用户ID
+//   - otherUID：另一个用户ID
+//
+// 返回值：
+//   - bool：存在屏蔽关系时返回 true。
+//   - error：如果在查询过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *RelationStore) IsBlockedBetween(uid uint64, otherUID uint64) (bool, error) {
+	var count int64
+	result := store.db.Model(&models.UserBlock{}).
+		Where("(blocker_uid = ? AND blocked_uid = ?) OR (blocker_uid = ? AND blocked_uid = ?)", uid, otherUID, otherUID, uid).
+		Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
+
+// CreateUserBlock 屏蔽用户，并解除双方之间的关注关系。
+//
+// 参数：
+//   - blockerUID：屏蔽者用户ID
+//   - blockedUID：被屏蔽者用户ID
+//
+// 返回值：
+//   - error：如果在屏蔽过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *RelationStore) CreateUserBlock(blockerUID uint64, blockedUID uint64) error {
+	return store.db.Transaction(func(tx *gorm.DB) error {
+		block := models.UserBlock{
+			BlockerUID: blockerUID,
+			BlockedUID: blockedUID,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&block)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Where(
+			"(follower_uid = ? AND followee_uid = ?) OR (follower_uid = ? AND followee_uid = ?)",
+			blockerUID, blockedUID, blockedUID, blockerUID,
+		).Delete(&models.UserFollow{}).Error
+	})
+}
+
+// DeleteUserBlock 解除屏蔽。
+//
+// 参数：
+//   - blockerUID：屏蔽者用户ID
+//   - blockedUID：被屏蔽者用户ID
+//
+// 返回值：
+//   - error：如果在解除过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *RelationStore) DeleteUserBlock(blockerUID uint64, blockedUID uint64) error {
+	return store.db.
+		Where("blocker_uid = ? AND blocked_uid = ?", blockerUID, blockedUID).
+		Delete(&models.UserBlock{}).Error
+}
@@ -0,0 +1,134 @@
+/*
+Package stores - NekoBlog backend server data access objects.
+This file is for timeline storage accessing.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package stores
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+)
+
+// TimelineStore 时间线存储
+type TimelineStore struct {
+	db *gorm.DB
+}
+
+// NewTimelineStore 返回一个新的时间线存储实例。
+//
+// 返回值：
+//   - *TimelineStore：新的时间线存储实例。
+func (factory *Factory) NewTimelineStore() *TimelineStore {
+	return &TimelineStore{factory.db}
+}
+
+// GetTimelineByFollows 以读扩散方式获取用户的时间线，即实时查询用户本人及其关注用户的博文。
+//
+// 参数：
+//   - uid：用户ID
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - []models.PostInfo：博文列表，会多返回一条用于判断是否存在下一页。
+//   - error：如果在查询过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *TimelineStore) GetTimelineByFollows(uid uint64, cursor *types.Cursor, limit int) ([]models.PostInfo, error) {
+	var posts []models.PostInfo
+	result := store.db.
+		Where("post_infos.uid = ? OR post_infos.uid IN (SELECT user_follows.followee_uid FROM user_follows WHERE user_follows.follower_uid = ?)", uid, uid).
+		Scopes(postVisibleTo(uid), paginateByCursor(cursor, limit)).
+		Find(&posts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return posts, nil
+}
+
+// GetTimelineByEntries 以写扩散方式获取用户的时间线，即读取预先推送的时间线条目。
+//
+// 条目的创建时间与博文一致，因此游标与读扩散模式通用。
+//
+// 参数：
+//   - uid：用户ID
+//   - cursor：分页游标，为 nil 时从第一页开始
+//   - limit：每页条目数
+//
+// 返回值：
+//   - []models.PostInfo：博文列表，会多返回一条用于判断是否存在下一页。
+//   - error：如果在查询过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *TimelineStore) GetTimelineByEntries(uid uint64, cursor *types.Cursor, limit int) ([]models.PostInfo, error) {
+	db := store.db.Model(&models.PostInfo{}).
+		Joins("JOIN timeline_entries ON timeline_entries.post_id = post_infos.id AND timeline_entries.uid = ?", uid).
+		Scopes(postVisibleTo(uid))
+	if cursor != nil {
+		db = db.Where("(timeline_entries.created_at, timeline_entries.post_id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	var posts []models.PostInfo
+	result := db.Order("timeline_entries.created_at desc, timeline_entries.post_id desc").
+		Limit(limit + 1).
+		Find(&posts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return posts, nil
+}
+
+// FanOutPost 将博文推送到作者本人及其全部关注者的时间线。
+//
+// 语句中的参数需要显式指定类型，否则 PostgreSQL 会将 SELECT 列表中的参数推断为 text。
+//
+// 参数：
+//   - post：博文
+//
+// 返回值：
+//   - error：如果在推送过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *TimelineStore) FanOutPost(post models.PostInfo) error {
+	return store.db.Exec(
+		"INSERT INTO timeline_entries (uid, post_id, author_uid, created_at) "+
+			"SELECT user_follows.follower_uid, CAST(? AS bigint), CAST(? AS bigint), CAST(? AS timestamptz) "+
+			"FROM user_follows WHERE user_follows.followee_uid = ? "+
+			"UNION ALL SELECT CAST(? AS bigint), CAST(? AS bigint), CAST(? AS bigint), CAST(? AS timestamptz) "+
+			"ON CONFLICT (uid, post_id) DO NOTHING",
+		post.ID, post.UID, post.CreatedAt, post.UID,
+		post.UID, post.ID, post.UID, post.CreatedAt,
+	).Error
+}
+
+// BackfillTimeline 将作者最近的博文补充到用户的时间线，用于写扩散模式下新关注用户时。
+//
+// 参数：
+//   - uid：时间线所属用户ID
+//   - authorUID：博文作者用户ID
+//   - limit：补充的最大博文数
+//
+// 返回值：
+//   - error：如果在补充过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *TimelineStore) BackfillTimeline(uid uint64, authorUID uint64, limit int) error {
+	return store.db.Exec(
+		"INSERT INTO timeline_entries (uid, post_id, author_uid, created_at) "+
+			"SELECT CAST(? AS bigint), post_infos.id, post_infos.uid, post_infos.created_at FROM post_infos "+
+			"WHERE post_infos.uid = ? AND post_infos.deleted_at IS NULL "+
+			"ORDER BY post_infos.created_at DESC LIMIT ? "+
+			"ON CONFLICT (uid, post_id) DO NOTHING",
+		uid, authorUID, limit,
+	).Error
+}
+
+// RemoveTimelineAuthor 从用户的时间线中移除指定作者的全部博文，用于写扩散模式下取消关注时。
+//
+// 参数：
+//   - uid：时间线所属用户ID
+//   - authorUID：博文作者用户ID
+//
+// 返回值：
+//   - error：如果在移除过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *TimelineStore) RemoveTimelineAuthor(uid uint64, authorUID uint64) error {
+	return store.db.
+		Where("uid = ? AND author_uid = ?", uid, authorUID).
+		Delete(&models.TimelineEntry{}).Error
+}
@@ -8,22 +8,20 @@ Copyright (c) [2024], Author(s):
 package stores
 
 import (
-	"bytes"
-	"errors"
-	"io"
-	"os"
-	"path/filepath"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/storages"
 	"github.com/Kirisakiii/neko-micro-blog-backend/types"
 )
 
 // UserStore 用户信息数据库
 type UserStore struct {
-	db *gorm.DB
+	db           *gorm.DB
+	mediaStorage storages.MediaStorage
 }
 
 // NewUserStore 返回一个新的 UserStore 实例。
@@ -31,41 +29,37 @@ type UserStore struct {
 // 返回值：
 //   - *UserStore：新的 UserStore 实例。
 func (factory *Factory) NewUserStore() *UserStore {
-	return &UserStore{factory.db}
+	return &UserStore{factory.db, factory.mediaStorage}
 }
 
-// RegisterUserByUsername 注册用户将提供的用户名、盐和哈希密码注册到数据库中。
+// RegisterUserByUsername 注册用户将提供的用户名和哈希密码注册到数据库中。
+//
+// 用户信息与认证信息在同一事务中创建，用户名重复时返回 gorm.ErrDuplicatedKey。
 //
 // 参数：
 //   - username：用户名
-//   - salt：盐值
 //   - hashedPassword：哈希密码
 //
 // 返回值：
 //   - error：如果在注册过程中发生错误，则返回相应的错误信息，否则返回nil。
-func (store *UserStore) RegisterUserByUsername(username string, salt string, hashedPassword string) error {
-	user := models.UserInfo{
-		UserName: username,
-		NickName: &username,
-	}
-	result := store.db.Create(&user)
-	if result.Error != nil {
-		return result.Error
-	}
-
-	uid := user.ID
-	userAuthInfo := models.UserAuthInfo{
-		UID:          uint64(uid),
-		UserName:     username,
-		Salt:         salt,
-		PasswordHash: hashedPassword,
-	}
-	result = store.db.Create(&userAuthInfo)
-	if result.Error != nil {
-		return result.Error
-	}
-
-	return nil
+func (store *UserStore) RegisterUserByUsername(username string, hashedPassword string) error {
+	return store.db.Transaction(func(tx *gorm.DB) error {
+		user := models.UserInfo{
+			UserName: username,
+			NickName: &username,
+		}
+		result := tx.Create(&user)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		userAuthInfo := models.UserAuthInfo{
+			UID:          uint64(user.ID),
+			UserName:     username,
+			PasswordHash: hashedPassword,
+		}
+		return tx.Create(&userAuthInfo).Error
+	})
 }
 
 // GetUserByUID 通过用户ID获取用户信息。
@@ -102,6 +96,65 @@ func (store *UserStore) GetUserByUsername(username string) (*models.UserInfo, er
 	return user, nil
 }
 
+// UpgradeUserPasswordHash 将用户的哈希密码升级为新的哈希。
+//
+// 仅当数据库中的哈希仍为旧哈希时才会更新，避免覆盖并发修改的密码。
+//
+// 参数：
+//   - uid：用户ID
+//   - oldHash：旧哈希
+//   - newHash：新哈希
+//
+// 返回值：
+//   - error：如果在更新过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) UpgradeUserPasswordHash(uid uint64, oldHash string, newHash string) error {
+	result := store.db.Model(&models.UserAuthInfo{}).
+		Where("uid = ? AND psw_hash = ?", uid, oldHash).
+		Updates(map[string]interface{}{
+			"salt":     "",
+			"psw_hash": newHash,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// GetUserByEmail 通过邮箱获取用户信息。
+//
+// 参数：
+//   - email：邮箱
+//
+// 返回值：
+//   - *models.UserInfo：如果找到了相应的用户信息，则返回该用户信息，否则返回nil。
+//   - error：如果在获取过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) GetUserByEmail(email string) (*models.UserInfo, error) {
+	user := new(models.UserInfo)
+	result := store.db.Where("email = ?", email).First(user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return user, nil
+}
+
+// GetUserAuthInfoByUID 通过用户ID获取用户的认证信息。
+//
+// 参数：
+//   - uid：用户ID
+//
+// 返回值：
+//   - *models.UserAuthInfo：如果找到了相应的用户认证信息，则返回该用户认证信息，否则返回nil。
+//   - error：如果在获取过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) GetUserAuthInfoByUID(uid uint64) (*models.UserAuthInfo, error) {
+	userAuthInfo := new(models.UserAuthInfo)
+	result := store.db.Where("uid = ?", uid).First(userAuthInfo)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return userAuthInfo, nil
+}
+
 // GetUserAuthInfoByUsername 通过用户名获取用户的认证信息。
 //
 // 参数：
@@ -134,23 +187,56 @@ func (store *UserStore) CreateUserLoginLog(userLoginLogInfo *models.UserLoginLog
 	return nil
 }
 
-// CreateUserAvaliableToken 创建一个可用的 Token。
+// GetUncheckedLoginEventsByUID 获取用户尚未确认的登录事件。
 //
 // 参数：
-//   - token：Token
-//   - claims：Token 的声明
+//   - uid：用户ID
+//   - limit：最多返回的事件数量
 //
 // 返回值：
-//   - error：如果在创建过程中发生错误，则返回相应的错误信息，否则返回nil。
-func (store *UserStore) CreateUserAvaliableToken(token string, claims *types.BearerTokenClaims) error {
-	userAvaliableToken := &models.UserAvaliableToken{
-		UID:        claims.UID,
-		Username:   claims.Username,
-		Token:      token,
-		ExpireTime: claims.ExpiresAt.Time,
+//   - []types.LoginEvent：未确认的登录事件，按登录时间倒序排列。
+//   - error：如果在获取过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) GetUncheckedLoginEventsByUID(uid uint64, limit int) ([]types.LoginEvent, error) {
+	events := make([]types.LoginEvent, 0)
+	result := store.db.Model(&models.UserLoginLog{}).
+		Select(
+			"user_login_logs.id, user_login_logs.login_time, user_login_logs.login_ip, user_login_logs.device, "+
+				"user_login_logs.application, user_login_logs.is_succeed, user_login_logs.reason, "+
+				"NOT EXISTS (?) AS is_new_device",
+			store.db.Table("user_login_logs AS previous").
+				Select("1").
+				Where(
+					"previous.uid = user_login_logs.uid AND previous.is_succeed = ? AND previous.device = user_login_logs.device "+
+						"AND previous.application = user_login_logs.application AND previous.login_time < user_login_logs.login_time "+
+						"AND previous.deleted_at IS NULL",
+					true,
+				),
+		).
+		Where("user_login_logs.uid = ? AND user_login_logs.if_checked = ?", uid, false).
+		Order("user_login_logs.login_time desc").
+		Limit(limit).
+		Scan(&events)
+	if result.Error != nil {
+		return nil, result.Error
 	}
+	return events, nil
+}
 
-	result := store.db.Create(userAvaliableToken)
+// CheckUserLoginLogs 将用户的登录日志标记为已确认。
+//
+// 参数：
+//   - uid：用户ID
+//   - ids：登录日志ID列表，为空时确认该用户的全部登录日志
+//
+// 返回值：
+//   - error：如果在更新过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) CheckUserLoginLogs(uid uint64, ids []uint64) error {
+	query := store.db.Model(&models.UserLoginLog{}).Where("uid = ? AND if_checked = ?", uid, false)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+
+	result := query.UpdateColumn("if_checked", true)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -158,64 +244,221 @@ func (store *UserStore) CreateUserAvaliableToken(token string, claims *types.Bea
 	return nil
 }
 
-// BanUserToken 将 Token 禁用。
+// ReserveLoginAttempt 原子地预留一次登录尝试。
+//
+// 计数与读取在同一条语句中完成，并发的尝试会得到各自递增后的计数。时间窗口已过期时重新计数。
 //
 // 参数：
-//   - token：Token
+//   - key：计数键
+//   - now：当前时间
+//   - window：时间窗口长度
 //
 // 返回值：
-//   - error：如果在禁用过程中发生错误，则返回相应的错误信息，否则返回nil。
-func (store *UserStore) BanUserToken(token string) error {
-	// 使用硬删除
-	result := store.db.Where("token = ?", token).Unscoped().Delete(&models.UserAvaliableToken{})
+//   - *types.LoginAttemptReservation：预留后的计数状态。
+//   - error：如果在预留过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) ReserveLoginAttempt(key string, now time.Time, window time.Duration) (*types.LoginAttemptReservation, error) {
+	cutoff := now.Add(-window)
+	reservation := new(types.LoginAttemptReservation)
+	result := store.db.Raw(
+		"INSERT INTO login_attempt_counters (counter_key, window_start, attempts) VALUES (?, CAST(? AS timestamptz), 1) "+
+			"ON CONFLICT (counter_key) DO UPDATE SET "+
+			"attempts = CASE WHEN login_attempt_counters.window_start <= CAST(? AS timestamptz) THEN 1 ELSE login_attempt_counters.attempts + 1 END, "+
+			"last_failure_at = CASE WHEN login_attempt_counters.window_start <= CAST(? AS timestamptz) THEN NULL ELSE login_attempt_counters.last_failure_at END, "+
+			"window_start = CASE WHEN login_attempt_counters.window_start <= CAST(? AS timestamptz) THEN EXCLUDED.window_start ELSE login_attempt_counters.window_start END "+
+			"RETURNING attempts, window_start, last_failure_at",
+		key, now, cutoff, cutoff, cutoff,
+	).Scan(reservation)
 	if result.Error != nil {
-		return result.Error
+		return nil, result.Error
 	}
+	return reservation, nil
+}
 
-	return nil
+// ReleaseLoginAttempt 释放一次已预留但不计为失败的登录尝试。
+//
+// 参数：
+//   - key：计数键
+//
+// 返回值：
+//   - error：如果在释放过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) ReleaseLoginAttempt(key string) error {
+	return store.db.Model(&models.LoginAttemptCounter{}).
+		Where("counter_key = ?", key).
+		UpdateColumn("attempts", gorm.Expr("GREATEST(attempts - 1, 0)")).
+		Error
 }
 
-// IsUserTokenAvaliable 检查 Token 是否可用。
+// RecordLoginAttemptFailure 将已预留的登录尝试记为失败，预留的计数保持不变。
 //
 // 参数：
-//   - token：Token
+//   - key：计数键
+//   - now：失败时间
 //
 // 返回值：
-//   - bool：如果 Token 可用，则返回 true，否则返回 false。
-//   - error：如果在检查过程中发生错误，则返回相应的错误信息，否则返回nil。
-func (store *UserStore) IsUserTokenAvaliable(token string) (bool, error) {
-	userAvaliableToken := new(models.UserAvaliableToken)
-	result := store.db.Where("token = ?", token).First(userAvaliableToken)
+//   - error：如果在记录过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) RecordLoginAttemptFailure(key string, now time.Time) error {
+	return store.db.Model(&models.LoginAttemptCounter{}).
+		Where("counter_key = ?", key).
+		UpdateColumn("last_failure_at", now).
+		Error
+}
 
-	// 如果记录不存在，则返回 false
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return false, nil
+// ResetLoginAttempts 登录成功后清除计数。
+//
+// 参数：
+//   - key：计数键
+//
+// 返回值：
+//   - error：如果在清除过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) ResetLoginAttempts(key string) error {
+	return store.db.Where("counter_key = ?", key).Delete(&models.LoginAttemptCounter{}).Error
+}
+
+// CreateUserRefreshToken 创建一个刷新令牌。
+//
+// 参数：
+//   - refreshToken：刷新令牌记录
+//
+// 返回值：
+//   - error：如果在创建过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) CreateUserRefreshToken(refreshToken *models.UserRefreshToken) error {
+	result := store.db.Create(refreshToken)
+	if result.Error != nil {
+		return result.Error
 	}
 
-	// 如果发生其他错误，则返回错误信息
+	return nil
+}
+
+// GetUserRefreshTokenByHash 通过哈希值获取刷新令牌。
+//
+// 参数：
+//   - tokenHash：刷新令牌哈希值
+//
+// 返回值：
+//   - *models.UserRefreshToken：如果找到了相应的刷新令牌，则返回该记录，否则返回nil。
+//   - error：如果在获取过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) GetUserRefreshTokenByHash(tokenHash string) (*models.UserRefreshToken, error) {
+	refreshToken := new(models.UserRefreshToken)
+	result := store.db.Where("token_hash = ?", tokenHash).First(refreshToken)
 	if result.Error != nil {
-		return false, result.Error
+		return nil, result.Error
 	}
+	return refreshToken, nil
+}
 
-	return true, nil
+// RotateUserRefreshToken 轮换刷新令牌。
+//
+// 在同一事务中将旧令牌标记为已使用并创建新令牌，旧令牌已被并发使用时不会创建新令牌。
+//
+// 参数：
+//   - oldTokenID：旧刷新令牌记录ID
+//   - newToken：新刷新令牌记录
+//
+// 返回值：
+//   - error：如果旧令牌已被使用，则返回 gorm.ErrRecordNotFound；如果在轮换过程中发生其他错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) RotateUserRefreshToken(oldTokenID uint, newToken *models.UserRefreshToken) error {
+	return store.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserRefreshToken{}).
+			Where("id = ? AND is_used = ?", oldTokenID, false).
+			UpdateColumn("is_used", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Create(newToken).Error
+	})
 }
 
-// GetUserAvaliableTokensByUsername 获取用户可用的 Token。
+// GetUserSessionsByUID 获取用户当前的所有有效会话。
+//
+// 会话信息由会话中未使用的刷新令牌与创建该会话时的登录日志联合查询得到。
 //
 // 参数：
-//   - username：用户名
+//   - uid：用户ID
 //
 // 返回值：
-//   - []models.UserAvaliableToken：用户可用的 Token。
+//   - []types.UserSession：用户的有效会话，按登录时间倒序排列。
 //   - error：如果在获取过程中发生错误，则返回相应的错误信息，否则返回nil。
-func (store *UserStore) GetUserAvaliableTokensByUsername(username string) ([]models.UserAvaliableToken, error) {
-	tokens := make([]models.UserAvaliableToken, 0)
-	// 按创建时间排序
-	result := store.db.Where("username = ?", username).Order("created_at asc").Find(&tokens)
+func (store *UserStore) GetUserSessionsByUID(uid uint64) ([]types.UserSession, error) {
+	sessions := make([]types.UserSession, 0)
+	result := store.db.Model(&models.UserRefreshToken{}).
+		Select(
+			"user_refresh_tokens.session_id, user_refresh_tokens.expire_time, user_login_logs.login_time AS created_at, "+
+				"user_login_logs.login_ip, user_login_logs.device, user_login_logs.application",
+		).
+		Joins("LEFT JOIN user_login_logs ON user_login_logs.session_id = user_refresh_tokens.session_id AND user_login_logs.deleted_at IS NULL").
+		Where("user_refresh_tokens.uid = ? AND user_refresh_tokens.is_used = ? AND user_refresh_tokens.expire_time > ?", uid, false, time.Now()).
+		Order("user_login_logs.login_time desc").
+		Scan(&sessions)
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	return tokens, nil
+	return sessions, nil
+}
+
+// BanUserSession 禁用会话，会话内的所有刷新令牌将被删除。
+//
+// 参数：
+//   - sessionID：会话ID
+//
+// 返回值：
+//   - error：如果在禁用过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) BanUserSession(sessionID string) error {
+	// 使用硬删除
+	result := store.db.Where("session_id = ?", sessionID).Unscoped().Delete(&models.UserRefreshToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// BanUserSessionByID 禁用用户的指定会话。
+//
+// 参数：
+//   - uid：用户ID
+//   - sessionID：会话ID
+//
+// 返回值：
+//   - error：如果会话不存在或不属于该用户，则返回 gorm.ErrRecordNotFound；如果在禁用过程中发生其他错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) BanUserSessionByID(uid uint64, sessionID string) error {
+	// 使用硬删除
+	result := store.db.Where("session_id = ? AND uid = ?", sessionID, uid).Unscoped().Delete(&models.UserRefreshToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// BanUserSessionsByUID 禁用用户的所有会话。
+//
+// 参数：
+//   - uid：用户ID
+//   - exceptSessionID：需要保留的会话ID，为空时禁用全部会话
+//
+// 返回值：
+//   - error：如果在禁用过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) BanUserSessionsByUID(uid uint64, exceptSessionID string) error {
+	query := store.db.Where("uid = ?", uid)
+	if exceptSessionID != "" {
+		query = query.Where("session_id <> ?", exceptSessionID)
+	}
+
+	// 使用硬删除
+	result := query.Unscoped().Delete(&models.UserRefreshToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
 }
 
 // SaveUserAvatarByUID 保存用户头像。
@@ -227,65 +470,281 @@ func (store *UserStore) GetUserAvaliableTokensByUsername(username string) ([]mod
 // 返回值：
 //   - error：如果在保存过程中发生错误，则返回相应的错误信息，否则返回nil。
 func (store *UserStore) SaveUserAvatarByUID(uid uint64, fileName string, data []byte) error {
-	savePath := filepath.Join("./public/avatars", fileName)
-
-	// 创建目标文件
-	file, err := os.Create(savePath)
+	// 将头像写入媒体存储
+	err := store.mediaStorage.Put(storages.AvatarKey(fileName), data, "image/webp")
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	// 使用 io.Copy 将数据写入文件
-	_, err = io.Copy(file, bytes.NewReader(data))
-	if err != nil {
-		return err
-	}
+	return store.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定用户记录并读取旧头像文件名
+		user := new(models.UserInfo)
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "avatar").
+			Where("id = ?", uid).
+			First(user)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// 仅更新头像列，避免覆盖并发修改的其他资料
+		result = tx.Model(&models.UserInfo{}).
+			Where("id = ?", uid).
+			Update("avatar", fileName)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// 将旧头像文件加入清理队列
+		if user.Avatar != "vanilla.webp" {
+			return tx.Create(&models.AvatarDeletionWaitList{
+				FileName: user.Avatar,
+			}).Error
+		}
+		return nil
+	})
+}
 
-	// 用户信息记录
-	user := new(models.UserInfo)
-	result := store.db.Where("id = ?", uid).First(user)
+// UpdateUserPasswordByUID 更新用户密码并撤销会话。
+//
+// 密码更新与会话撤销在同一事务中完成。
+//
+// 参数：
+//   - uid：用户ID
+//   - hashedNewPassword：经过哈希处理的新密码
+//   - exceptSessionID：需要保留的会话ID，为空时撤销全部会话
+//
+// 返回值：
+//   - error：如果在更新过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) UpdateUserPasswordByUID(uid uint64, hashedNewPassword string, exceptSessionID string) error {
+	return store.db.Transaction(func(tx *gorm.DB) error {
+		// 新哈希自带盐，清空旧版本单独保存的盐
+		result := tx.Model(&models.UserAuthInfo{}).
+			Where("uid = ?", uid).
+			Updates(map[string]interface{}{
+				"salt":     "",
+				"psw_hash": hashedNewPassword,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// 撤销会话，使用硬删除
+		query := tx.Where("uid = ?", uid)
+		if exceptSessionID != "" {
+			query = query.Where("session_id <> ?", exceptSessionID)
+		}
+		return query.Unscoped().Delete(&models.UserRefreshToken{}).Error
+	})
+}
+
+// VerifyUserEmailByUID 将已验证的邮箱写入用户信息。
+//
+// 待验证的邮箱仅保存在一次性令牌中，验证通过后才写入用户信息，由唯一约束保证邮箱不重复。
+//
+// 参数：
+//   - uid：用户ID
+//   - email：已验证的邮箱
+//
+// 返回值：
+//   - error：如果邮箱已被其他用户使用，则返回 gorm.ErrDuplicatedKey；如果用户不存在，则返回 gorm.ErrRecordNotFound；如果在更新过程中发生其他错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) VerifyUserEmailByUID(uid uint64, email string) error {
+	result := store.db.Model(&models.UserInfo{}).
+		Where("id = ?", uid).
+		Updates(map[string]interface{}{
+			"email":          email,
+			"email_verified": true,
+		})
 	if result.Error != nil {
 		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
 
-	// 将旧头像文件加入清理队列
-	if user.Avatar != "vanilla.webp" {
-		store.db.Create(&models.AvatarDeletionWaitList{
-			FileName: user.Avatar,
-		})
+	return nil
+}
+
+// CreateUserOneTimeToken 创建一次性令牌。
+//
+// 同一用户同一用途尚未使用的旧令牌将一并作废。
+//
+// 参数：
+//   - token：一次性令牌记录
+//
+// 返回值：
+//   - error：如果在创建过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) CreateUserOneTimeToken(token *models.UserOneTimeToken) error {
+	return store.db.Transaction(func(tx *gorm.DB) error {
+		// 作废旧令牌，使用硬删除
+		result := tx.Where("uid = ? AND purpose = ? AND used_at IS NULL", token.UID, token.Purpose).
+			Unscoped().
+			Delete(&models.UserOneTimeToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Create(token).Error
+	})
+}
+
+// ConsumeUserOneTimeToken 使用一次性令牌。
+//
+// 令牌在同一条语句中被校验并标记为已使用，因此并发请求中只有一个能够成功。
+//
+// 参数：
+//   - tokenHash：令牌哈希值
+//   - purpose：令牌用途
+//
+// 返回值：
+//   - *models.UserOneTimeToken：被使用的令牌记录。
+//   - error：如果令牌不存在、已使用或已过期，则返回 gorm.ErrRecordNotFound；如果在更新过程中发生其他错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) ConsumeUserOneTimeToken(tokenHash string, purpose string) (*models.UserOneTimeToken, error) {
+	now := time.Now()
+	token := new(models.UserOneTimeToken)
+	result := store.db.Model(token).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expire_time > ?", tokenHash, purpose, now).
+		UpdateColumn("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
 	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return token, nil
+}
 
-	// 更新头像文件名
-	user.Avatar = fileName
-	result = store.db.Save(user)
+// SetUserTOTPSecret 保存待激活的两步验证密钥。
+//
+// 参数：
+//   - uid：用户ID
+//   - secret：base32 编码的密钥
+//
+// 返回值：
+//   - error：如果用户已启用两步验证，则返回 gorm.ErrRecordNotFound；如果在保存过程中发生其他错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) SetUserTOTPSecret(uid uint64, secret string) error {
+	result := store.db.Model(&models.UserAuthInfo{}).
+		Where("uid = ? AND totp_enabled = ?", uid, false).
+		Updates(map[string]interface{}{
+			"totp_secret":    secret,
+			"totp_last_step": 0,
+		})
 	if result.Error != nil {
 		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
 
 	return nil
 }
 
-// UpdateUserPasswordByUsername 更新用户密码。
+// EnableUserTOTP 启用两步验证并替换恢复码。
 //
 // 参数：
-//   - username：用户名
-//   - hashedNewPassword：经过哈希处理的新密码
+//   - uid：用户ID
+//   - step：激活时使用的验证码时间步
+//   - recoveryCodeHashes：恢复码哈希值列表
 //
 // 返回值：
-//   - error：如果在更新过程中发生错误，则返回相应的错误信息，否则返回nil。
-func (store *UserStore) UpdateUserPasswordByUsername(username string, hashedNewPassword string) error {
-	userAuthInfo := new(models.UserAuthInfo)
-	result := store.db.Where("username = ?", username).First(userAuthInfo)
+//   - error：如果用户没有待激活的密钥，则返回 gorm.ErrRecordNotFound；如果在启用过程中发生其他错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) EnableUserTOTP(uid uint64, step int64, recoveryCodeHashes []string) error {
+	return store.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserAuthInfo{}).
+			Where("uid = ? AND totp_enabled = ? AND totp_secret IS NOT NULL", uid, false).
+			Updates(map[string]interface{}{
+				"totp_enabled":   true,
+				"totp_last_step": step,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// 替换恢复码，使用硬删除
+		result = tx.Where("uid = ?", uid).Unscoped().Delete(&models.UserRecoveryCode{})
+		if result.Error != nil {
+			return result.Error
+		}
+		recoveryCodes := make([]models.UserRecoveryCode, 0, len(recoveryCodeHashes))
+		for _, codeHash := range recoveryCodeHashes {
+			recoveryCodes = append(recoveryCodes, models.UserRecoveryCode{UID: uid, CodeHash: codeHash})
+		}
+		return tx.Create(&recoveryCodes).Error
+	})
+}
+
+// DisableUserTOTP 关闭两步验证并删除密钥与恢复码。
+//
+// 参数：
+//   - uid：用户ID
+//
+// 返回值：
+//   - error：如果在关闭过程中发生错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) DisableUserTOTP(uid uint64) error {
+	return store.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserAuthInfo{}).
+			Where("uid = ?", uid).
+			Updates(map[string]interface{}{
+				"totp_secret":    nil,
+				"totp_enabled":   false,
+				"totp_last_step": 0,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// 删除恢复码，使用硬删除
+		return tx.Where("uid = ?", uid).Unscoped().Delete(&models.UserRecoveryCode{}).Error
+	})
+}
+
+// UseUserTOTPStep 记录已使用的验证码时间步，同一时间步及更早的验证码不能再次使用。
+//
+// 参数：
+//   - uid：用户ID
+//   - step：验证码时间步
+//
+// 返回值：
+//   - error：如果该时间步已被使用，则返回 gorm.ErrRecordNotFound；如果在更新过程中发生其他错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) UseUserTOTPStep(uid uint64, step int64) error {
+	result := store.db.Model(&models.UserAuthInfo{}).
+		Where("uid = ? AND totp_last_step < ?", uid, step).
+		UpdateColumn("totp_last_step", step)
 	if result.Error != nil {
 		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
 
-	userAuthInfo.PasswordHash = hashedNewPassword
-	result = store.db.Save(userAuthInfo)
+// ConsumeUserRecoveryCode 使用恢复码。
+//
+// 参数：
+//   - uid：用户ID
+//   - codeHash：恢复码哈希值
+//
+// 返回值：
+//   - error：如果恢复码不存在或已使用，则返回 gorm.ErrRecordNotFound；如果在更新过程中发生其他错误，则返回相应的错误信息，否则返回nil。
+func (store *UserStore) ConsumeUserRecoveryCode(uid uint64, codeHash string) error {
+	result := store.db.Model(&models.UserRecoveryCode{}).
+		Where("uid = ? AND code_hash = ? AND used_at IS NULL", uid, codeHash).
+		UpdateColumn("used_at", time.Now())
 	if result.Error != nil {
 		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
 
 	return nil
 }
@@ -299,16 +758,20 @@ func (store *UserStore) UpdateUserPasswordByUsername(username string, hashedNewP
 // 返回值：
 //   - error：如果在更新过程中发生错误，则返回相应的错误信息，否则返回nil。
 func (store *UserStore) UpdateUserInfoByUID(uid uint64, updatedProfile *models.UserInfo) error {
-	var userProfile models.UserInfo
-	result := store.db.Where("id = ?", uid).First(&userProfile)
+	// 仅更新资料列，避免覆盖并发修改的头像、邮箱与权限
+	result := store.db.Model(&models.UserInfo{}).
+		Where("id = ?", uid).
+		Updates(map[string]interface{}{
+			"nickname": updatedProfile.NickName,
+			"birth":    updatedProfile.Birth,
+			"gender":   updatedProfile.Gender,
+		})
 	if result.Error != nil {
 		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
 
-	userProfile.UpdatedAt = time.Now()
-	userProfile.NickName = updatedProfile.NickName
-	userProfile.Birth = updatedProfile.Birth
-	userProfile.Gender = updatedProfile.Gender
-
-	return store.db.Save(&userProfile).Error
+	return nil
 }
@@ -0,0 +1,75 @@
+/*
+Package stores - NekoBlog backend server data access objects.
+This file is for content visibility scopes.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package stores
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
+)
+
+// postVisibleTo 过滤出访问者可见的博文。
+//
+// 参数：
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//
+// 返回值：
+//   - func(*gorm.DB) *gorm.DB：gorm 查询作用域。
+func postVisibleTo(viewerUID uint64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(postVisibilityExpr(viewerUID))
+	}
+}
+
+// commentVisibleTo 过滤出访问者可见的评论。
+//
+// 参数：
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//
+// 返回值：
+//   - func(*gorm.DB) *gorm.DB：gorm 查询作用域。
+func commentVisibleTo(viewerUID uint64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(commentVisibilityExpr(viewerUID))
+	}
+}
+
+// postVisibilityExpr 返回博文对访问者可见的查询条件。
+//
+// 公开博文所有人可见，仅关注者可见的博文对作者的关注者可见，其余博文仅作者本人可见。
+//
+// 参数：
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//
+// 返回值：
+//   - clause.Expr：查询条件。
+func postVisibilityExpr(viewerUID uint64) clause.Expr {
+	return gorm.Expr(
+		"(post_infos.visibility = ? OR post_infos.uid = ? OR "+
+			"(post_infos.visibility = ? AND post_infos.uid IN (SELECT user_follows.followee_uid FROM user_follows WHERE user_follows.follower_uid = ?)))",
+		consts.VISIBILITY_PUBLIC, viewerUID, consts.VISIBILITY_FOLLOWERS, viewerUID,
+	)
+}
+
+// commentVisibilityExpr 返回评论对访问者可见的查询条件。
+//
+// 评论所属的博文必须对访问者可见；非公开评论仅评论者与博文作者可见。
+//
+// 参数：
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//
+// 返回值：
+//   - clause.Expr：查询条件。
+func commentVisibilityExpr(viewerUID uint64) clause.Expr {
+	return gorm.Expr(
+		"(comment_infos.post_id IN (SELECT post_infos.id FROM post_infos WHERE post_infos.deleted_at IS NULL AND ?) "+
+			"AND (comment_infos.is_public = ? OR comment_infos.uid = ? OR "+
+			"comment_infos.post_id IN (SELECT post_infos.id FROM post_infos WHERE post_infos.uid = ?)))",
+		postVisibilityExpr(viewerUID), true, viewerUID, viewerUID,
+	)
+}
@@ -0,0 +1,15 @@
+/*
+Package type - NekoBlog backend server types.
+This file is for pagination cursor types.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package types
+
+import "time"
+
+// Cursor 分页游标，指向上一页最后一条记录
+type Cursor struct {
+	CreatedAt time.Time // 创建时间
+	ID        uint64    // 记录ID
+}
@@ -0,0 +1,16 @@
+/*
+Package type - NekoBlog backend server types.
+This file is for password hashing related types.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package types
+
+// PasswordHashParams argon2id 密码哈希参数
+type PasswordHashParams struct {
+	Memory      uint32 // 内存开销，单位为 KiB
+	Iterations  uint32 // 迭代次数
+	Parallelism uint8  // 并行度
+	SaltLength  uint32 // 盐的字节数
+	KeyLength   uint32 // 哈希值的字节数
+}
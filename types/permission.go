@@ -0,0 +1,22 @@
+/*
+Package type - NekoBlog backend server types.
+This file is for permission related types.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package types
+
+// ResourceAction 资源操作类型
+type ResourceAction int
+
+// 资源操作类型
+const (
+	// RESOURCE_ACTION_UPDATE 修改资源
+	RESOURCE_ACTION_UPDATE ResourceAction = iota
+
+	// RESOURCE_ACTION_DELETE 删除资源
+	RESOURCE_ACTION_DELETE
+
+	// RESOURCE_ACTION_VIEW_HISTORY 查看资源的修订记录
+	RESOURCE_ACTION_VIEW_HISTORY
+)
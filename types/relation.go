@@ -0,0 +1,14 @@
+/*
+Package types - NekoBlog backend server types.
+This file is for user relationship related types.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package types
+
+// UserRelationStats 用户关系统计
+type UserRelationStats struct {
+	FollowerCount  int64 // 粉丝数
+	FollowingCount int64 // 关注数
+	IsFollowing    bool  // 访问者是否关注了该用户
+}
@@ -12,10 +12,64 @@ type UserAuthBody struct {
 	Password string `json:"password"` // 密码
 }
 
-// UserRegisterBody 注册请求体
+// UserTOTPLoginBody 两步验证登录请求体
+type UserTOTPLoginBody struct {
+	ChallengeToken string `json:"challenge_token" form:"challenge_token"` // 挑战令牌
+	Code           string `json:"code" form:"code"`                       // 验证码或恢复码
+}
+
+// UserTOTPEnrollBody 注册两步验证请求体
+type UserTOTPEnrollBody struct {
+	Password string `json:"password" form:"password"` // 密码
+}
+
+// UserTOTPActivateBody 启用两步验证请求体
+type UserTOTPActivateBody struct {
+	Code string `json:"code" form:"code"` // 验证码
+}
+
+// UserTOTPDisableBody 关闭两步验证请求体
+type UserTOTPDisableBody struct {
+	Password string `json:"password" form:"password"` // 密码
+	Code     string `json:"code" form:"code"`         // 验证码或恢复码
+}
+
+// UserRefreshTokenBody 刷新令牌请求体
+type UserRefreshTokenBody struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token"` // 刷新令牌
+}
+
+// UserLoginEventAckBody 确认登录事件请求体
+type UserLoginEventAckBody struct {
+	IDs []uint64 `json:"ids" form:"ids"` // 登录事件ID列表，为空时确认全部事件
+}
+
+// UserUpdatePasswordBody 修改密码请求体
 type UserUpdatePasswordBody struct {
-	UserAuthBody        // 认证请求体
-	NewPassword  string `json:"new_password"` // 新密码
+	Password           string `json:"password" form:"password"`                         // 原密码
+	NewPassword        string `json:"new_password" form:"new_password"`                 // 新密码
+	KeepCurrentSession bool   `json:"keep_current_session" form:"keep_current_session"` // 是否保留当前会话
+}
+
+// UserUpdateEmailBody 修改邮箱请求体
+type UserUpdateEmailBody struct {
+	Email string `json:"email" form:"email"` // 邮箱
+}
+
+// UserVerifyEmailBody 验证邮箱请求体
+type UserVerifyEmailBody struct {
+	Token string `json:"token" form:"token"` // 验证令牌
+}
+
+// UserPasswordResetRequestBody 请求重置密码请求体
+type UserPasswordResetRequestBody struct {
+	Email string `json:"email" form:"email"` // 已验证的邮箱
+}
+
+// UserPasswordResetConfirmBody 确认重置密码请求体
+type UserPasswordResetConfirmBody struct {
+	Token       string `json:"token" form:"token"`               // 重置令牌
+	NewPassword string `json:"new_password" form:"new_password"` // 新密码
 }
 
 // UserUpdateProfileBody 更新用户资料请求体
@@ -27,8 +81,10 @@ type UserUpdateProfileBody struct {
 
 // CommentCreatebody 创建评论请求体
 type UserCommentCreateBody struct {
-	PostID  *uint64 `json:"post_id" form:"post_id"` // 博文ID
-	Content string  `json:"content" form:"content"` // 内容
+	PostID   *uint64 `json:"post_id" form:"post_id"`     // 博文ID
+	ParentID *uint64 `json:"parent_id" form:"parent_id"` // 回复的评论ID
+	Content  string  `json:"content" form:"content"`     // 内容
+	IsPublic *bool   `json:"is_public" form:"is_public"` // 是否公开，默认公开
 }
 
 // UserCommentUpdateBody 更新评论请求体
@@ -46,11 +102,44 @@ type UserPostInfo struct {
 
 // PostCreateBody 创建博文请求体
 type PostCreateBody struct {
-	Title   string `json:"title" form:"title"`     //标题
-	Content string `json:"content" form:"content"` //内容
+	Title      string `json:"title" form:"title"`           //标题
+	Content    string `json:"content" form:"content"`       //内容
+	Visibility string `json:"visibility" form:"visibility"` // 可见性 public followers private
+}
+
+// PostUpdateVisibilityBody 修改博文可见性请求体
+type PostUpdateVisibilityBody struct {
+	Visibility string `json:"visibility" form:"visibility"` // 可见性 public followers private
+}
+
+// PostEditBody 编辑博文请求体，未提供的字段保持不变
+//
+// 新图片通过 multipart 表单的 images 字段上传，追加在保留的图片之后。
+type PostEditBody struct {
+	Title        *string  `json:"title" form:"title"`                 // 标题
+	Content      *string  `json:"content" form:"content"`             // 内容
+	Visibility   *string  `json:"visibility" form:"visibility"`       // 可见性 public followers private
+	RemoveImages []string `json:"remove_images" form:"remove_images"` // 需要移除的图片文件名
+}
+
+// PostForwardBody 转发博文请求体
+type PostForwardBody struct {
+	ParentPostID *uint64 `json:"parent_post_id" form:"parent_post_id"` // 被转发的博文ID
+	Title        string  `json:"title" form:"title"`                   // 标题
+	Content      string  `json:"content" form:"content"`               // 转发时附带的内容
 }
 
 // UserPostInfo 创建博文请求体
 type UserCommentDeleteBody struct {
 	CommentID *uint64 `json:"comment_id" form:"comment_id"` // 评论ID
 }
+
+// UserCommentRestoreBody 恢复评论请求体
+type UserCommentRestoreBody struct {
+	CommentID *uint64 `json:"comment_id" form:"comment_id"` // 评论ID
+}
+
+// UserCommentReactionBody 点赞、点踩评论请求体
+type UserCommentReactionBody struct {
+	CommentID *uint64 `json:"comment_id" form:"comment_id"` // 评论ID
+}
@@ -6,11 +6,90 @@ Copyright (c) [2024], Author(s):
 */
 package types
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 // BeaerTokenClaims Bearer Token 声明
 type BearerTokenClaims struct {
+	jwt.RegisteredClaims
+	UID       uint64 `json:"uid"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid"` // 令牌所属会话ID
+}
+
+// ChallengeTokenClaims 两步验证挑战令牌声明
+type ChallengeTokenClaims struct {
 	jwt.RegisteredClaims
 	UID      uint64 `json:"uid"`
 	Username string `json:"username"`
 }
+
+// LoginChallenge 开启两步验证的用户通过密码验证后返回的挑战
+type LoginChallenge struct {
+	ChallengeToken string    // 挑战令牌
+	ExpireTime     time.Time // 挑战令牌过期时间
+}
+
+// TOTPEnrollment 两步验证注册信息
+type TOTPEnrollment struct {
+	Secret          string // base32 编码的密钥
+	ProvisioningURI string // 用于身份验证器扫码的 otpauth URI
+}
+
+// TokenKey 令牌密钥
+type TokenKey struct {
+	ID        string            // 密钥ID
+	Method    jwt.SigningMethod // 签名算法
+	SignKey   interface{}       // 签名密钥，仅用于校验的密钥为 nil
+	VerifyKey interface{}       // 校验密钥
+}
+
+// TokenSettings 令牌签发与校验设置
+type TokenSettings struct {
+	Issuer                string               // 令牌签发者
+	AccessExpireDuration  time.Duration        // 访问令牌有效期
+	RefreshExpireDuration time.Duration        // 刷新令牌有效期
+	MaxTokensPerUser      int                  // 每个用户最多同时持有的会话数量
+	SigningKey            *TokenKey            // 用于签发令牌的密钥
+	Keys                  map[string]*TokenKey // 可用于校验令牌的密钥，以密钥ID为键
+}
+
+// TokenPair 登录或刷新时签发的令牌对
+type TokenPair struct {
+	AccessToken       string    // 访问令牌
+	AccessExpireTime  time.Time // 访问令牌过期时间
+	RefreshToken      string    // 刷新令牌
+	RefreshExpireTime time.Time // 刷新令牌过期时间
+}
+
+// UserSession 用户会话信息，由会话中当前有效的刷新令牌与对应的登录日志联合得到
+type UserSession struct {
+	SessionID   string    // 会话ID
+	CreatedAt   time.Time // 会话创建时间，即登录时间
+	ExpireTime  time.Time // 会话过期时间，即当前刷新令牌的过期时间
+	LoginIP     string    // 登录IP
+	Device      string    // 登录设备
+	Application string    // 登录应用
+}
+
+// LoginAttemptReservation 预留登录尝试后的计数状态
+type LoginAttemptReservation struct {
+	Attempts      int64      // 包括本次尝试在内，时间窗口内失败及进行中的尝试次数
+	WindowStart   time.Time  // 当前时间窗口的起始时间
+	LastFailureAt *time.Time // 最近一次失败的时间，没有失败记录时为 nil
+}
+
+// LoginEvent 登录事件，由登录日志及该设备是否曾成功登录得到
+type LoginEvent struct {
+	ID          uint      // 登录日志ID
+	LoginTime   time.Time // 登录时间
+	LoginIP     string    // 登录IP
+	Device      string    // 登录设备
+	Application string    // 登录应用
+	IsSucceed   bool      // 是否登录成功
+	Reason      string    // 失败原因
+	IsNewDevice bool      // 该设备与应用此前是否从未成功登录
+}
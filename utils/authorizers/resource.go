@@ -0,0 +1,49 @@
+/*
+Package authorizers - NekoBlog backend server authorization utilities.
+This file is for resource ownership and authority based authorization.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package authorizers
+
+import (
+	"errors"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
+	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+)
+
+// ErrPermissionDenied 权限不足
+var ErrPermissionDenied = errors.New("permission denied")
+
+// overrideAuthorities 非资源所有者执行各操作所需的最低权限等级
+var overrideAuthorities = map[types.ResourceAction]uint64{
+	types.RESOURCE_ACTION_UPDATE:       consts.AUTHORITY_ADMIN,
+	types.RESOURCE_ACTION_DELETE:       consts.AUTHORITY_MODERATOR,
+	types.RESOURCE_ACTION_VIEW_HISTORY: consts.AUTHORITY_MODERATOR,
+}
+
+// AuthorizeResourceAction 校验用户是否有权对资源执行指定操作。
+//
+// 资源所有者总是可以操作自己的资源，其他用户需要达到该操作对应的权限等级。
+//
+// 参数：
+//   - operator：执行操作的用户信息
+//   - ownerUID：资源所有者的用户ID
+//   - action：操作类型
+//
+// 返回值：
+//   - error：如果有权操作，则返回nil，否则返回 ErrPermissionDenied。
+func AuthorizeResourceAction(operator *models.UserInfo, ownerUID uint64, action types.ResourceAction) error {
+	if uint64(operator.ID) == ownerUID {
+		return nil
+	}
+
+	authority, ok := overrideAuthorities[action]
+	if ok && operator.Authority >= authority {
+		return nil
+	}
+
+	return ErrPermissionDenied
+}
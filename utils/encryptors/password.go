@@ -7,48 +7,162 @@ Copyright (c) [2024], Author(s):
 package encryptors
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
 )
 
-// HashPassword 生成哈希密码。
+// argon2idPrefix argon2id 哈希的 PHC 格式前缀
+const argon2idPrefix = "$argon2id$"
+
+// ErrPasswordMismatch 密码与哈希不匹配
+var ErrPasswordMismatch = errors.New("password does not match")
+
+// HashPassword 使用 argon2id 生成哈希密码。
+//
+// 哈希以 PHC 格式保存算法、版本、参数与盐，如：
+// $argon2id$v=19$m=65536,t=3,p=2$<盐>$<哈希>
 //
 // 参数：
 //   - password：密码
-//   - salt：盐
+//   - params：argon2id 参数
 //
 // 返回值：
 //   - string：生成的哈希密码。
 //   - error：如果在生成哈希密码的过程中发生错误，则返回相应的错误信息，否则返回nil。
-func HashPassword(password string, salt string) (string, error) {
-	// 将密码和盐拼接在一起
-	passwordWithSalt := append([]byte(password), []byte(salt)...)
-
-	// 生成哈希密码
-	hashedPassword, err := bcrypt.GenerateFromPassword(
-		passwordWithSalt,
-		bcrypt.DefaultCost,
-	)
+func HashPassword(password string, params *types.PasswordHashParams) (string, error) {
+	// 生成盐
+	salt := make([]byte, params.SaltLength)
+	_, err := rand.Read(salt)
 	if err != nil {
 		return "", err
 	}
 
+	// 生成哈希密码
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
 	// 返回哈希密码
-	return string(hashedPassword), nil
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
 }
 
 // CompareHashPassword 比较哈希密码。
 //
+// 同时支持 argon2id 哈希与旧版本的 bcrypt 哈希，后者需要拼接单独保存的盐。
+//
 // 参数：
 //   - hashedPassword：哈希密码
 //   - password：密码
-//   - salt：盐
+//   - legacySalt：旧版本 bcrypt 哈希使用的盐，argon2id 哈希忽略此参数
 //
 // 返回值：
 //   - error：如果密码匹配，则返回nil，否则返回相应的错误信息。
-func CompareHashPassword(hashedPassword string, password string, salt string) error {
-	// 将密码和盐拼接在一起
-	passwordWithSalt := append([]byte(password), []byte(salt)...)
+func CompareHashPassword(hashedPassword string, password string, legacySalt string) error {
+	// 旧版本 bcrypt 哈希
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		// 将密码和盐拼接在一起
+		passwordWithSalt := append([]byte(password), []byte(legacySalt)...)
+		return bcrypt.CompareHashAndPassword([]byte(hashedPassword), passwordWithSalt)
+	}
+
+	// 解析 argon2id 哈希
+	params, salt, key, err := decodeArgon2idHash(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	// 使用相同参数重新计算并比较
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+// NeedsRehash 检查哈希密码是否需要使用当前参数重新生成。
+//
+// 旧版本 bcrypt 哈希、无法解析的哈希以及参数与当前配置不同的 argon2id 哈希均需要重新生成。
+//
+// 参数：
+//   - hashedPassword：哈希密码
+//   - params：当前的 argon2id 参数
+//
+// 返回值：
+//   - bool：是否需要重新生成。
+func NeedsRehash(hashedPassword string, params *types.PasswordHashParams) bool {
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		return true
+	}
+
+	currentParams, salt, _, err := decodeArgon2idHash(hashedPassword)
+	if err != nil {
+		return true
+	}
+	return currentParams.Memory != params.Memory ||
+		currentParams.Iterations != params.Iterations ||
+		currentParams.Parallelism != params.Parallelism ||
+		currentParams.KeyLength != params.KeyLength ||
+		uint32(len(salt)) != params.SaltLength
+}
+
+// decodeArgon2idHash 解析 PHC 格式的 argon2id 哈希。
+//
+// 参数：
+//   - hashedPassword：哈希密码
+//
+// 返回值：
+//   - *types.PasswordHashParams：哈希使用的参数。
+//   - []byte：盐。
+//   - []byte：哈希值。
+//   - error：如果哈希格式不正确，则返回相应的错误信息，否则返回nil。
+func decodeArgon2idHash(hashedPassword string) (*types.PasswordHashParams, []byte, []byte, error) {
+	// $argon2id$v=19$m=65536,t=3,p=2$<盐>$<哈希>
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, errors.New("invalid argon2id hash format")
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("unsupported argon2 version: %d", version)
+	}
+
+	params := new(types.PasswordHashParams)
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
 
-	// 比较哈希密码
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), passwordWithSalt)
+	return params, salt, key, nil
 }
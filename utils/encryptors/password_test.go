@@ -0,0 +1,158 @@
+/*
+Package encryptors - NekoBlog backend server data encryptors.
+This file is for password encryptor tests.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package encryptors
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+)
+
+// testHashParams 测试使用的低开销 argon2id 参数
+var testHashParams = &types.PasswordHashParams{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// TestHashPasswordRoundTrip 校验 PHC 格式哈希的生成、解析与比较。
+func TestHashPasswordRoundTrip(t *testing.T) {
+	hashed, err := HashPassword("correct horse", testHashParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hashed, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("HashPassword() = %q, want PHC argon2id prefix", hashed)
+	}
+
+	params, salt, key, err := decodeArgon2idHash(hashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *params != *testHashParams {
+		t.Errorf("decodeArgon2idHash() params = %+v, want %+v", *params, *testHashParams)
+	}
+	if uint32(len(salt)) != testHashParams.SaltLength || uint32(len(key)) != testHashParams.KeyLength {
+		t.Errorf("decodeArgon2idHash() salt/key length = %d/%d", len(salt), len(key))
+	}
+
+	cases := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{"matching password", "correct horse", false},
+		{"wrong password", "correct horse!", true},
+		{"empty password", "", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := CompareHashPassword(hashed, c.password, "ignored-salt")
+			if (err != nil) != c.wantErr {
+				t.Fatalf("CompareHashPassword() error = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+}
+
+// TestDecodeArgon2idHashMalformed 校验格式错误的哈希被拒绝。
+func TestDecodeArgon2idHashMalformed(t *testing.T) {
+	cases := []struct {
+		name   string
+		hashed string
+	}{
+		{"missing fields", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA"},
+		{"wrong algorithm", "$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5"},
+		{"wrong version", "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5"},
+		{"bad parameters", "$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5"},
+		{"bad salt encoding", "$argon2id$v=19$m=1024,t=1,p=1$!!$a2V5"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, _, _, err := decodeArgon2idHash(c.hashed); err == nil {
+				t.Fatalf("decodeArgon2idHash(%q) accepted a malformed hash", c.hashed)
+			}
+			if err := CompareHashPassword(c.hashed, "password", ""); err == nil {
+				t.Fatalf("CompareHashPassword(%q) accepted a malformed hash", c.hashed)
+			}
+		})
+	}
+}
+
+// TestCompareHashPasswordLegacyBcrypt 校验旧版本拼接盐的 bcrypt 哈希仍可验证。
+func TestCompareHashPasswordLegacyBcrypt(t *testing.T) {
+	legacySalt := "legacy-salt"
+	hashed, err := bcrypt.GenerateFromPassword([]byte("old password"+legacySalt), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		password string
+		salt     string
+		wantErr  bool
+	}{
+		{"matching password and salt", "old password", legacySalt, false},
+		{"wrong password", "old passw0rd", legacySalt, true},
+		{"missing salt", "old password", "", true},
+		{"wrong salt", "old password", "other-salt", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := CompareHashPassword(string(hashed), c.password, c.salt)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("CompareHashPassword() error = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+}
+
+// TestNeedsRehash 校验旧版本哈希与参数变更后的哈希需要重新生成。
+func TestNeedsRehash(t *testing.T) {
+	hashed, err := HashPassword("password", testHashParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	with := func(modify func(params *types.PasswordHashParams)) *types.PasswordHashParams {
+		params := *testHashParams
+		modify(&params)
+		return &params
+	}
+	cases := []struct {
+		name   string
+		hashed string
+		params *types.PasswordHashParams
+		want   bool
+	}{
+		{"same parameters", hashed, testHashParams, false},
+		{"memory changed", hashed, with(func(p *types.PasswordHashParams) { p.Memory = 2048 }), true},
+		{"iterations changed", hashed, with(func(p *types.PasswordHashParams) { p.Iterations = 2 }), true},
+		{"parallelism changed", hashed, with(func(p *types.PasswordHashParams) { p.Parallelism = 2 }), true},
+		{"salt length changed", hashed, with(func(p *types.PasswordHashParams) { p.SaltLength = 32 }), true},
+		{"key length changed", hashed, with(func(p *types.PasswordHashParams) { p.KeyLength = 64 }), true},
+		{"legacy bcrypt", string(legacy), testHashParams, true},
+		{"malformed argon2id", "$argon2id$broken", testHashParams, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := NeedsRehash(c.hashed, c.params); got != c.want {
+				t.Fatalf("NeedsRehash() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+/*
+Package encryptors - NekoBlog backend server data encryptors.
+This file is for secret token encryptors.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package encryptors
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashToken 计算随机令牌的哈希值。
+//
+// 随机令牌本身具有足够的熵，因此使用 SHA-256 即可，无需慢哈希。
+//
+// 参数：
+//   - token：随机令牌
+//
+// 返回值：
+//   - string：十六进制编码的哈希值。
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
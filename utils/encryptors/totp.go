@@ -0,0 +1,43 @@
+/*
+Package encryptors - NekoBlog backend server data encryptors.
+This file is for TOTP code computation (RFC 6238).
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package encryptors
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+)
+
+// ComputeHOTP 计算 HOTP 验证码（RFC 4226，HMAC-SHA1）。
+//
+// 参数：
+//   - secret：密钥
+//   - counter：计数器，TOTP 中为时间步序号
+//   - digits：验证码位数
+//
+// 返回值：
+//   - string：左侧补零的验证码。
+func ComputeHOTP(secret []byte, counter uint64, digits int) string {
+	// 计算 HMAC
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, counter)
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	// 动态截断
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	// 取低位数字
+	modulo := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, code%modulo)
+}
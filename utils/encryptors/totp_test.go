@@ -0,0 +1,47 @@
+/*
+Package encryptors - NekoBlog backend server data encryptors.
+This file is for TOTP code computation tests.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package encryptors
+
+import "testing"
+
+// rfcSecret RFC 4226 与 RFC 6238 附录中 HMAC-SHA1 使用的密钥
+var rfcSecret = []byte("12345678901234567890")
+
+// TestComputeHOTP 使用 RFC 4226 附录 D 的测试向量校验 HOTP 计算。
+func TestComputeHOTP(t *testing.T) {
+	expected := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+	for counter, want := range expected {
+		got := ComputeHOTP(rfcSecret, uint64(counter), 6)
+		if got != want {
+			t.Errorf("ComputeHOTP(counter=%d) = %s, want %s", counter, got, want)
+		}
+	}
+}
+
+// TestComputeHOTPTOTPVectors 使用 RFC 6238 附录 B 的 SHA1 测试向量校验以时间步为计数器的验证码。
+func TestComputeHOTPTOTPVectors(t *testing.T) {
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, c := range cases {
+		got := ComputeHOTP(rfcSecret, uint64(c.unix/30), 8)
+		if got != c.want {
+			t.Errorf("ComputeHOTP(time=%d) = %s, want %s", c.unix, got, c.want)
+		}
+	}
+}
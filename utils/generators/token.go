@@ -7,6 +7,8 @@ Copyright (c) [2024], Author(s):
 package generators
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -16,36 +18,100 @@ import (
 	"github.com/Kirisakiii/neko-micro-blog-backend/types"
 )
 
-// GenerateToken 生成一个新的令牌。
+// GenerateToken 生成一个新的访问令牌。
 //
 // 参数：
+//   - settings：令牌设置
 //   - uid：用户ID
 //   - username：用户名
+//   - sessionID：令牌所属会话ID
 //
 // 返回值：
 //   - string：新的令牌。
+//   - *types.BearerTokenClaims：令牌的声明。
 //   - error：如果在生成过程中发生错误，则返回相应的错误信息，否则返回nil。
-func GenerateToken(uid uint64, username string) (string, *types.BearerTokenClaims, error) {
+func GenerateToken(settings *types.TokenSettings, uid uint64, username string, sessionID string) (string, *types.BearerTokenClaims, error) {
 	// 构造 Token 的 Claims
 	claims := &types.BearerTokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(consts.TOKEN_EXPIRE_DURATION * time.Second)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(settings.AccessExpireDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    consts.TOKEN_ISSUER,
-			Subject:   "BearerToken",
+			Issuer:    settings.Issuer,
+			Subject:   consts.TOKEN_SUBJECT_BEARER,
 			ID:        uuid.New().String(),
 		},
-		UID:      uid,
-		Username: username,
+		UID:       uid,
+		Username:  username,
+		SessionID: sessionID,
 	}
 
-	// 生成 Token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
 	// 签名 Token
-	tokenString, err := token.SignedString([]byte(consts.TOKEN_SECRET))
+	tokenString, err := signToken(settings, claims)
 
 	// 返回 Token 和 Claims
 	return tokenString, claims, err
 }
+
+// GenerateChallengeToken 生成一个两步验证挑战令牌。
+//
+// 挑战令牌表明用户已通过密码验证，仅可用于提交两步验证码，不能作为访问令牌使用。
+//
+// 参数：
+//   - settings：令牌设置
+//   - uid：用户ID
+//   - username：用户名
+//
+// 返回值：
+//   - string：新的挑战令牌。
+//   - *types.ChallengeTokenClaims：令牌的声明。
+//   - error：如果在生成过程中发生错误，则返回相应的错误信息，否则返回nil。
+func GenerateChallengeToken(settings *types.TokenSettings, uid uint64, username string) (string, *types.ChallengeTokenClaims, error) {
+	claims := &types.ChallengeTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(consts.TOTP_CHALLENGE_EXPIRE)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    settings.Issuer,
+			Subject:   consts.TOKEN_SUBJECT_TOTP_CHALLENGE,
+			ID:        uuid.New().String(),
+		},
+		UID:      uid,
+		Username: username,
+	}
+
+	tokenString, err := signToken(settings, claims)
+	return tokenString, claims, err
+}
+
+// signToken 使用当前签发密钥签名令牌。
+//
+// 参数：
+//   - settings：令牌设置
+//   - claims：令牌的声明
+//
+// 返回值：
+//   - string：签名后的令牌。
+//   - error：如果在签名过程中发生错误，则返回相应的错误信息，否则返回nil。
+func signToken(settings *types.TokenSettings, claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(settings.SigningKey.Method, claims)
+	token.Header["kid"] = settings.SigningKey.ID
+	return token.SignedString(settings.SigningKey.SignKey)
+}
+
+// GenerateSecretToken 生成一个不透明的随机令牌，用于刷新令牌等仅需比对哈希值的场景。
+//
+// 参数：
+//   - numBytes：随机字节数
+//
+// 返回值：
+//   - string：base64url 编码的随机令牌。
+//   - error：如果在生成过程中发生错误，则返回相应的错误信息，否则返回nil。
+func GenerateSecretToken(numBytes int) (string, error) {
+	randomBytes := make([]byte, numBytes)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
@@ -0,0 +1,81 @@
+/*
+Package generators - NekoBlog backend server generator utils
+This file is for TOTP secret, provisioning URI and recovery code generator.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package generators
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
+)
+
+// GenerateTOTPSecret 生成 base32 编码的 TOTP 密钥。
+//
+// 返回值：
+//   - string：不带填充的 base32 编码密钥。
+//   - error：如果在生成过程中发生错误，则返回相应的错误信息，否则返回nil。
+func GenerateTOTPSecret() (string, error) {
+	randomBytes := make([]byte, consts.TOTP_SECRET_BYTES)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes), nil
+}
+
+// GenerateTOTPURI 生成用于身份验证器扫码的 otpauth URI。
+//
+// 参数：
+//   - account：账户名
+//   - secret：base32 编码的密钥
+//
+// 返回值：
+//   - string：otpauth://totp/ URI。
+func GenerateTOTPURI(account string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", consts.TOTP_ISSUER)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(consts.TOTP_DIGITS))
+	query.Set("period", strconv.Itoa(consts.TOTP_PERIOD))
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + consts.TOTP_ISSUER + ":" + account,
+		RawQuery: query.Encode(),
+	}
+	return uri.String()
+}
+
+// GenerateRecoveryCodes 生成两步验证恢复码。
+//
+// 每个恢复码由 8 个 base32 字符组成，以 xxxx-xxxx 的形式展示。
+//
+// 参数：
+//   - count：恢复码数量
+//
+// 返回值：
+//   - []string：恢复码列表。
+//   - error：如果在生成过程中发生错误，则返回相应的错误信息，否则返回nil。
+func GenerateRecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		// 5 字节恰好编码为 8 个 base32 字符
+		randomBytes := make([]byte, 5)
+		_, err := rand.Read(randomBytes)
+		if err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(randomBytes))
+		codes = append(codes, code[:4]+"-"+code[4:])
+	}
+	return codes, nil
+}
@@ -0,0 +1,51 @@
+/*
+Package parsers - NekoBlog backend server data parsing utilities.
+This file is for pagination cursor parsing.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package parsers
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+)
+
+// ParseCursor 解析分页游标。
+//
+// 参数：
+//   - cursorString：游标字符串。
+//
+// 返回值：
+//   - *types.Cursor：解析得到的分页游标。
+//   - error：如果游标格式不正确，则返回相应的错误信息，否则返回nil。
+func ParseCursor(cursorString string) (*types.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursorString)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	// 游标格式为 "创建时间微秒时间戳_记录ID"
+	parts := strings.SplitN(string(data), "_", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("invalid cursor")
+	}
+	createdAt, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &types.Cursor{
+		CreatedAt: time.UnixMicro(createdAt),
+		ID:        id,
+	}, nil
+}
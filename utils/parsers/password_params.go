@@ -0,0 +1,48 @@
+/*
+Package parsers - NekoBlog backend server data parsing utilities.
+This file is for password hashing parameters parsing.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package parsers
+
+import (
+	"errors"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/configs"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+)
+
+// ParsePasswordHashParams 根据配置文件解析 argon2id 密码哈希参数。
+//
+// 参数：
+//   - cfg：配置文件对象
+//
+// 返回值：
+//   - *types.PasswordHashParams：密码哈希参数。
+//   - error：如果配置不合法，则返回相应的错误信息，否则返回nil。
+func ParsePasswordHashParams(cfg *configs.Config) (*types.PasswordHashParams, error) {
+	params := &types.PasswordHashParams{
+		Memory:      cfg.Password.Memory,
+		Iterations:  cfg.Password.Iterations,
+		Parallelism: cfg.Password.Parallelism,
+		SaltLength:  cfg.Password.SaltLength,
+		KeyLength:   cfg.Password.KeyLength,
+	}
+
+	if params.Iterations < 1 || params.Parallelism < 1 {
+		return nil, errors.New("argon2id iterations and parallelism must be positive")
+	}
+	// argon2 要求内存开销不低于 8 * 并行度 KiB
+	if params.Memory < 8*uint32(params.Parallelism) {
+		return nil, errors.New("argon2id memory is too small for the configured parallelism")
+	}
+	if params.SaltLength < 8 {
+		return nil, errors.New("argon2id salt length must be at least 8 bytes")
+	}
+	if params.KeyLength < 16 {
+		return nil, errors.New("argon2id key length must be at least 16 bytes")
+	}
+
+	return params, nil
+}
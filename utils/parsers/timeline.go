@@ -0,0 +1,33 @@
+/*
+Package parsers - NekoBlog backend server data parsing utilities.
+This file is for timeline settings parsing.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package parsers
+
+import (
+	"errors"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/configs"
+	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
+)
+
+// ParseTimelineStrategy 根据配置文件解析时间线生成策略。
+//
+// 参数：
+//   - cfg：配置文件对象
+//
+// 返回值：
+//   - string：时间线生成策略，未配置时为读扩散。
+//   - error：如果配置不合法，则返回相应的错误信息，否则返回nil。
+func ParseTimelineStrategy(cfg *configs.Config) (string, error) {
+	switch cfg.Timeline.Strategy {
+	case "":
+		return consts.TIMELINE_STRATEGY_READ, nil
+	case consts.TIMELINE_STRATEGY_READ, consts.TIMELINE_STRATEGY_WRITE:
+		return cfg.Timeline.Strategy, nil
+	default:
+		return "", errors.New("unsupported timeline strategy: " + cfg.Timeline.Strategy)
+	}
+}
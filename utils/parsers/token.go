@@ -7,27 +7,79 @@ Copyright (c) [2024], Author(s):
 package parsers
 
 import (
+	"errors"
+
 	"github.com/golang-jwt/jwt/v5"
 
 	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
 	"github.com/Kirisakiii/neko-micro-blog-backend/types"
 )
 
-// ParseToken 解析令牌。
+// ParseToken 解析访问令牌。
+//
+// 根据令牌头部的 kid 选择校验密钥，因此密钥轮换期内新旧密钥签发的令牌均可通过校验。
+// 未携带 kid 的令牌使用当前签发密钥校验。
 //
 // 参数：
+//   - settings：令牌设置。
 //   - token：令牌字符串。
 //
 // 返回值：
 //   - *BearerTokenClaims：令牌中的声明。
 //   - error：如果在解析过程中发生错误，则返回相应的错误信息，否则返回nil。
-func ParseToken(token string) (*types.BearerTokenClaims, error) {
-	// 解析令牌
+func ParseToken(settings *types.TokenSettings, token string) (*types.BearerTokenClaims, error) {
 	claims := new(types.BearerTokenClaims)
-	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(consts.TOKEN_SECRET), nil
-	})
+	err := parseClaims(settings, token, claims, consts.TOKEN_SUBJECT_BEARER)
+	return claims, err
+}
 
-	// 返回结果
+// ParseChallengeToken 解析两步验证挑战令牌。
+//
+// 参数：
+//   - settings：令牌设置。
+//   - token：令牌字符串。
+//
+// 返回值：
+//   - *types.ChallengeTokenClaims：令牌中的声明。
+//   - error：如果在解析过程中发生错误，则返回相应的错误信息，否则返回nil。
+func ParseChallengeToken(settings *types.TokenSettings, token string) (*types.ChallengeTokenClaims, error) {
+	claims := new(types.ChallengeTokenClaims)
+	err := parseClaims(settings, token, claims, consts.TOKEN_SUBJECT_TOTP_CHALLENGE)
 	return claims, err
 }
+
+// parseClaims 校验令牌签名、签发者与主题，并解析声明。
+//
+// 参数：
+//   - settings：令牌设置。
+//   - token：令牌字符串。
+//   - claims：用于接收声明的结构体。
+//   - subject：期望的令牌主题。
+//
+// 返回值：
+//   - error：如果在解析过程中发生错误，则返回相应的错误信息，否则返回nil。
+func parseClaims(settings *types.TokenSettings, token string, claims jwt.Claims, subject string) error {
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		// 根据 kid 选择密钥
+		key := settings.SigningKey
+		if kid, ok := token.Header["kid"]; ok {
+			kidString, ok := kid.(string)
+			if !ok {
+				return nil, errors.New("invalid key id")
+			}
+			key, ok = settings.Keys[kidString]
+			if !ok {
+				return nil, errors.New("unknown key id")
+			}
+		}
+
+		// 校验签名算法与密钥是否匹配
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, errors.New("unexpected signing method")
+		}
+
+		return key.VerifyKey, nil
+	}, jwt.WithIssuer(settings.Issuer), jwt.WithSubject(subject))
+
+	return err
+}
@@ -0,0 +1,176 @@
+/*
+Package parsers - NekoBlog backend server data parsing utilities.
+This file is for token settings parsing.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package parsers
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/configs"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+)
+
+const (
+	// minTokenSecretLength HS256 密钥的最小字节数
+	minTokenSecretLength = 32
+
+	// sampleTokenSecret 早期示例配置文件中附带的密钥，所有使用它的部署共享同一密钥，因此拒绝加载
+	sampleTokenSecret = "NEKO_MICRO_BLOG_BACKEND_EXAMPLE_SECRET"
+)
+
+// ParseTokenSettings 根据配置文件解析令牌设置并加载密钥。
+//
+// 参数：
+//   - cfg：配置文件对象
+//
+// 返回值：
+//   - *types.TokenSettings：令牌设置。
+//   - error：如果配置不合法或密钥加载失败，则返回相应的错误信息，否则返回nil。
+func ParseTokenSettings(cfg *configs.Config) (*types.TokenSettings, error) {
+	if cfg.Token.AccessExpireDuration <= 0 {
+		return nil, errors.New("access token expire duration must be positive")
+	}
+	if cfg.Token.RefreshExpireDuration <= 0 {
+		return nil, errors.New("refresh token expire duration must be positive")
+	}
+	if cfg.Token.MaxTokensPerUser <= 0 {
+		return nil, errors.New("max tokens per user must be positive")
+	}
+
+	settings := &types.TokenSettings{
+		Issuer:                cfg.Token.Issuer,
+		AccessExpireDuration:  time.Duration(cfg.Token.AccessExpireDuration) * time.Second,
+		RefreshExpireDuration: time.Duration(cfg.Token.RefreshExpireDuration) * time.Second,
+		MaxTokensPerUser:      cfg.Token.MaxTokensPerUser,
+		Keys:                  make(map[string]*types.TokenKey),
+	}
+
+	// 加载密钥
+	for _, keyConfig := range cfg.Token.Keys {
+		if keyConfig.ID == "" {
+			return nil, errors.New("token key id is required")
+		}
+		if _, ok := settings.Keys[keyConfig.ID]; ok {
+			return nil, fmt.Errorf("duplicated token key id: %s", keyConfig.ID)
+		}
+		key, err := parseTokenKey(keyConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load token key %s: %w", keyConfig.ID, err)
+		}
+		settings.Keys[key.ID] = key
+	}
+
+	// 校验签发密钥
+	signingKey, ok := settings.Keys[cfg.Token.SigningKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not configured", cfg.Token.SigningKeyID)
+	}
+	if signingKey.SignKey == nil {
+		return nil, fmt.Errorf("signing key %s has no private key", cfg.Token.SigningKeyID)
+	}
+	settings.SigningKey = signingKey
+
+	return settings, nil
+}
+
+// parseTokenKey 根据密钥配置加载密钥。
+//
+// 参数：
+//   - keyConfig：密钥配置
+//
+// 返回值：
+//   - *types.TokenKey：令牌密钥。
+//   - error：如果加载失败，则返回相应的错误信息，否则返回nil。
+func parseTokenKey(keyConfig configs.TokenKeyConfig) (*types.TokenKey, error) {
+	key := &types.TokenKey{ID: keyConfig.ID}
+
+	switch keyConfig.Algorithm {
+	case "HS256":
+		if keyConfig.Secret == "" {
+			return nil, errors.New("secret is required for HS256")
+		}
+		if keyConfig.Secret == sampleTokenSecret {
+			return nil, errors.New("the sample secret must be replaced")
+		}
+		if len(keyConfig.Secret) < minTokenSecretLength {
+			return nil, fmt.Errorf("secret must be at least %d bytes for HS256", minTokenSecretLength)
+		}
+		key.Method = jwt.SigningMethodHS256
+		key.SignKey = []byte(keyConfig.Secret)
+		key.VerifyKey = []byte(keyConfig.Secret)
+
+	case "RS256":
+		key.Method = jwt.SigningMethodRS256
+		if keyConfig.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(keyConfig.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.SignKey = privateKey
+			key.VerifyKey = &privateKey.PublicKey
+		}
+		if keyConfig.PublicKeyFile != "" {
+			pem, err := os.ReadFile(keyConfig.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.VerifyKey = publicKey
+		}
+
+	case "EdDSA":
+		key.Method = jwt.SigningMethodEdDSA
+		if keyConfig.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(keyConfig.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			privateKey, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			edPrivateKey, ok := privateKey.(ed25519.PrivateKey)
+			if !ok {
+				return nil, errors.New("private key is not an ed25519 key")
+			}
+			key.SignKey = edPrivateKey
+			key.VerifyKey = edPrivateKey.Public()
+		}
+		if keyConfig.PublicKeyFile != "" {
+			pem, err := os.ReadFile(keyConfig.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			publicKey, err := jwt.ParseEdPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.VerifyKey = publicKey
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", keyConfig.Algorithm)
+	}
+
+	if key.VerifyKey == nil {
+		return nil, errors.New("private key file or public key file is required")
+	}
+
+	return key, nil
+}
@@ -1,53 +1,75 @@
 package serializers
 
 import (
+	"slices"
+
 	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
 )
 
+// CommentListResponse 评论列表响应结构
 type CommentListResponse struct {
-	IDs []uint64 `json:"ids"`
+	IDs        []uint64 `json:"ids"`         // 评论ID列表
+	NextCursor *string  `json:"next_cursor"` // 下一页游标，没有下一页时为 null
 }
 
 // NewCommentListResponse 创建评论列表的响应
 //
 // 参数：
 //   - 评论体列表
+//   - 下一页的游标
 //
 // 返回值：
 //   - 评论列表的响应
-func NewCommentListResponse(commentInfos []models.CommentInfo) CommentListResponse {
-	var ids []uint64
+func NewCommentListResponse(commentInfos []models.CommentInfo, nextCursor *types.Cursor) CommentListResponse {
+	ids := make([]uint64, 0)
 	for _, commentInfos := range commentInfos {
 		ids = append(ids, uint64(commentInfos.ID))
 	}
-	return CommentListResponse{IDs: ids}
+	return CommentListResponse{IDs: ids, NextCursor: newCursorString(nextCursor)}
 }
 
 // CommentDetailResponse 文章信息响应结构
-// TODO: 实现 Replies 字段
 type CommentDetailResponse struct {
-	CommentID     uint64 `json:"comment_id"`     // 评论ID
-	PostID        uint64 `json:"post_id"`        // 博文ID
-	PosterUID     uint64 `json:"poster_uid"`     // 发布者UID
-	PostTimestamp int64  `json:"post_timestamp"` // 博文发布时间戳
-	Content       string `json:"content"`        // 内容
-	Likes         int    `json:"likes"`          // 点赞数
-	Replies       int    `json:"replies"`        // 回复数
-	Is_liked      bool   `json:"is_liked"`       // 是否点赞
-	Is_disliked   bool   `json:"is_disliked"`    // 是否点踩
+	CommentID     uint64  `json:"comment_id"`     // 评论ID
+	PostID        uint64  `json:"post_id"`        // 博文ID
+	ParentID      *uint64 `json:"parent_id"`      // 回复的评论ID
+	RootID        *uint64 `json:"root_id"`        // 所属楼层的根评论ID
+	PosterUID     uint64  `json:"poster_uid"`     // 发布者UID
+	PostTimestamp int64   `json:"post_timestamp"` // 博文发布时间戳
+	Content       string  `json:"content"`        // 内容
+	Likes         int     `json:"likes"`          // 点赞数
+	Replies       int     `json:"replies"`        // 回复数
+	Is_liked      bool    `json:"is_liked"`       // 是否点赞
+	Is_disliked   bool    `json:"is_disliked"`    // 是否点踩
 }
 
 // NewCommentDetailResponse 创建评论实例
-func NewCommentDetailResponse(comment models.CommentInfo) *CommentDetailResponse {
+//
+// 参数：
+//   - comment：评论信息模型
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//
+// 返回值：
+//   - *CommentDetailResponse：新的评论信息响应结构
+func NewCommentDetailResponse(comment models.CommentInfo, viewerUID uint64) *CommentDetailResponse {
 	// 创建一个新的 CommentProfileData 实例
-	// TODO: 实现 Is_liked / Is_disliked
 	profileData := &CommentDetailResponse{
 		CommentID:     uint64(comment.ID),
 		PostID:        comment.PostID,
+		ParentID:      comment.ParentID,
+		RootID:        comment.RootID,
 		PosterUID:     comment.UID,
 		PostTimestamp: comment.CreatedAt.Unix(),
 		Content:       comment.Content,
 		Likes:         len(comment.Like),
+		Replies:       int(comment.ReplyCount),
+	}
+
+	// 设置访问者相关字段
+	if viewerUID != 0 {
+		profileData.Is_liked = slices.Contains(comment.Like, int64(viewerUID))
+		profileData.Is_disliked = slices.Contains(comment.Dislike, int64(viewerUID))
 	}
 
 	return profileData
@@ -65,3 +87,40 @@ func NewCreateCommentResponse(commentInfo models.CommentInfo) CreateCommentRespo
 	}
 	return resp
 }
+
+// TrashedCommentData 回收站评论响应结构
+type TrashedCommentData struct {
+	CommentID     uint64 `json:"comment_id"`     // 评论ID
+	PostID        uint64 `json:"post_id"`        // 博文ID
+	Content       string `json:"content"`        // 内容
+	PostTimestamp int64  `json:"post_timestamp"` // 评论发布时间戳
+	DeletedAt     int64  `json:"deleted_at"`     // 删除时间戳
+}
+
+// TrashedCommentListResponse 回收站评论列表响应结构
+type TrashedCommentListResponse struct {
+	Comments   []TrashedCommentData `json:"comments"`    // 评论列表
+	NextCursor *string              `json:"next_cursor"` // 下一页游标，没有下一页时为 null
+}
+
+// NewTrashedCommentListResponse 创建回收站评论列表的响应
+//
+// 参数：
+//   - comments：已删除的评论信息模型列表
+//   - nextCursor：下一页的游标
+//
+// 返回值：
+//   - 回收站评论列表的响应
+func NewTrashedCommentListResponse(comments []models.CommentInfo, nextCursor *types.Cursor) TrashedCommentListResponse {
+	data := make([]TrashedCommentData, 0, len(comments))
+	for _, comment := range comments {
+		data = append(data, TrashedCommentData{
+			CommentID:     uint64(comment.ID),
+			PostID:        comment.PostID,
+			Content:       comment.Content,
+			PostTimestamp: comment.CreatedAt.Unix(),
+			DeletedAt:     comment.DeletedAt.Time.Unix(),
+		})
+	}
+	return TrashedCommentListResponse{Comments: data, NextCursor: newCursorString(nextCursor)}
+}
@@ -0,0 +1,38 @@
+/*
+Package serializers - NekoBlog backend server data serialization.
+This file is for pagination cursor serialization.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package serializers
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
+)
+
+// newCursorString 将分页游标编码为响应中的字符串。
+//
+// 游标格式为 "创建时间微秒时间戳_记录ID" 的 base64url 编码，与 parsers.ParseCursor 对应。
+//
+// 参数：
+//   - cursor：分页游标
+//
+// 返回值：
+//   - *string：编码后的游标，游标为 nil 时返回 nil。
+func newCursorString(cursor *types.Cursor) *string {
+	if cursor == nil {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strconv.FormatInt(cursor.CreatedAt.UnixMicro(), 10))
+	sb.WriteRune('_')
+	sb.WriteString(strconv.FormatUint(cursor.ID, 10))
+
+	cursorString := base64.RawURLEncoding.EncodeToString([]byte(sb.String()))
+	return &cursorString
+}
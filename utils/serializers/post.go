@@ -1,42 +1,84 @@
 package serializers
 
 import (
+	"slices"
+
 	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/storages"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
 )
 
+// postSummaryMaxLength 博文摘要内容的最大长度
+const postSummaryMaxLength = 140
+
+// PostListResponse 博文列表响应结构
 type PostListResponse struct {
-	IDs []uint64 `json:"ids"`
+	IDs        []uint64 `json:"ids"`         // 博文ID列表
+	NextCursor *string  `json:"next_cursor"` // 下一页游标，没有下一页时为 null
 }
 
-func NewPostListResponse(postInfos []models.PostInfo) *PostListResponse {
+// NewPostListResponse 创建博文列表的响应
+//
+// 参数：
+//   - postInfos：当前页的博文信息
+//   - nextCursor：下一页的游标
+//
+// 返回值：
+//   - *PostListResponse：博文列表的响应
+func NewPostListResponse(postInfos []models.PostInfo, nextCursor *types.Cursor) *PostListResponse {
 	ids := make([]uint64, 0)
 	for _, postInfo := range postInfos {
 		ids = append(ids, uint64(postInfo.ID))
 	}
-	return &PostListResponse{IDs: ids}
+	return &PostListResponse{IDs: ids, NextCursor: newCursorString(nextCursor)}
 }
 
 // PostDetailResponse 文章信息响应结构
 type PostDetailResponse struct {
-	CommentID    uint64   `json:"comment_id"`   //
-	UID          uint64   `json:"uid"`          // 用户ID
-	Title        string   `json:"title"`        // 标题
-	Content      string   `json:"content"`      // 内容
-	ParentPostID *uint64  `json:"ParentPostID"` // 转发自文章ID
-	Images       []string `json:"images"`       // 图片
-	Like         int      `json:"like"`         // 点赞数
-	Favorite     int      `json:"favorite"`     // 收藏数
-	Farward      int      `json:"farward"`      // 转发数
+	CommentID     uint64   `json:"comment_id"`     //
+	UID           uint64   `json:"uid"`            // 用户ID
+	Title         string   `json:"title"`          // 标题
+	Content       string   `json:"content"`        // 内容
+	ParentPostID  *uint64  `json:"ParentPostID"`   // 转发自文章ID
+	Images        []string `json:"images"`         // 图片文件名
+	ImageURLs     []string `json:"image_urls"`     // 图片 URL，与图片文件名一一对应
+	Like          int      `json:"like"`           // 点赞数
+	Favorite      int      `json:"favorite"`       // 收藏数
+	Farward       int      `json:"farward"`        // 转发数
+	Visibility    string   `json:"visibility"`     // 可见性
+	IsLiked       bool     `json:"is_liked"`       // 访问者是否已点赞
+	IsFavorited   bool     `json:"is_favorited"`   // 访问者是否已收藏
+	EditedAt      *int64   `json:"edited_at"`      // 最近一次编辑时间戳，未编辑过时为 null
+	RevisionCount uint64   `json:"revision_count"` // 修订次数
+
+	ParentPost *PostSummaryResponse `json:"parent_post"` // 原博文摘要，非转发博文时为 null
+}
+
+// PostSummaryResponse 博文摘要响应结构，用于渲染转发引用卡片
+type PostSummaryResponse struct {
+	PostID        uint64   `json:"post_id"`        // 博文ID
+	UID           uint64   `json:"uid"`            // 用户ID
+	Title         string   `json:"title"`          // 标题
+	Content       string   `json:"content"`        // 内容摘要
+	Images        []string `json:"images"`         // 图片文件名
+	ImageURLs     []string `json:"image_urls"`     // 图片 URL，与图片文件名一一对应
+	PostTimestamp int64    `json:"post_timestamp"` // 博文发布时间戳
+	IsDeleted     bool     `json:"is_deleted"`     // 原博文是否已被删除
+	IsUnavailable bool     `json:"is_unavailable"` // 原博文是否存在但对访问者不可见
 }
 
 // NewPostDetailResponse 创建新的文章信息响应
 //
 // 参数：
 //   - model：文章信息模型
+//   - parent：原博文信息模型，非转发博文或原博文已删除、不可见时为 nil
+//   - parentDeleted：原博文是否已被删除
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//   - mediaStorage：媒体文件存储，用于生成图片 URL
 //
 // 返回值：
 //   - *PostProfileData：新的文章信息响应结构
-func NewPostDetailResponse(post models.PostInfo) *PostDetailResponse {
+func NewPostDetailResponse(post models.PostInfo, parent *models.PostInfo, parentDeleted bool, viewerUID uint64, mediaStorage storages.MediaStorage) *PostDetailResponse {
 	// 创建一个新的 PostProfileData 实例
 	profileData := &PostDetailResponse{
 		CommentID:    uint64(post.ID),
@@ -45,14 +87,73 @@ func NewPostDetailResponse(post models.PostInfo) *PostDetailResponse {
 		Content:      post.Content,
 		ParentPostID: post.ParentPostID,
 		Images:       post.Images,
+		ImageURLs:    newPostImageURLs(post.Images, mediaStorage),
 		Like:         len(post.Like),
-		Favorite:     len(post.Farward),
+		Favorite:     len(post.Favorite),
 		Farward:      len(post.Farward),
+		Visibility:   post.Visibility,
+	}
+
+	// 设置编辑信息
+	if post.EditedAt != nil {
+		editedAt := post.EditedAt.Unix()
+		profileData.EditedAt = &editedAt
+	}
+	profileData.RevisionCount = post.RevisionCount
+
+	// 设置访问者相关字段
+	if viewerUID != 0 {
+		profileData.IsLiked = slices.Contains(post.Like, int64(viewerUID))
+		profileData.IsFavorited = slices.Contains(post.Favorite, int64(viewerUID))
+	}
+
+	// 设置原博文摘要
+	if post.ParentPostID != nil {
+		profileData.ParentPost = NewPostSummaryResponse(*post.ParentPostID, parent, parentDeleted, mediaStorage)
 	}
 
 	return profileData
 }
 
+// NewPostSummaryResponse 创建新的博文摘要响应
+//
+// 参数：
+//   - postID：博文ID
+//   - post：博文信息模型，博文已删除或不可见时为 nil
+//   - deleted：博文是否已被删除，为 false 且 post 为 nil 时表示博文对访问者不可见
+//   - mediaStorage：媒体文件存储，用于生成图片 URL
+//
+// 返回值：
+//   - *PostSummaryResponse：新的博文摘要响应结构
+func NewPostSummaryResponse(postID uint64, post *models.PostInfo, deleted bool, mediaStorage storages.MediaStorage) *PostSummaryResponse {
+	// 博文已被删除或不可见时仅返回ID
+	if post == nil {
+		return &PostSummaryResponse{
+			PostID:        postID,
+			Images:        make([]string, 0),
+			ImageURLs:     make([]string, 0),
+			IsDeleted:     deleted,
+			IsUnavailable: !deleted,
+		}
+	}
+
+	// 截取内容摘要
+	content := []rune(post.Content)
+	if len(content) > postSummaryMaxLength {
+		content = content[:postSummaryMaxLength]
+	}
+
+	return &PostSummaryResponse{
+		PostID:        uint64(post.ID),
+		UID:           post.UID,
+		Title:         post.Title,
+		Content:       string(content),
+		Images:        post.Images,
+		ImageURLs:     newPostImageURLs(post.Images, mediaStorage),
+		PostTimestamp: post.CreatedAt.Unix(),
+	}
+}
+
 // CreatePostResponse 用于将 PostInfo 转换为 JSON 格式的结构体
 type CreatePostResponse struct {
 	ID uint64 `json:"id"`
@@ -65,3 +166,113 @@ func NewCreatePostResponse(postInfo models.PostInfo) CreatePostResponse {
 	}
 	return resp
 }
+
+// PostRevisionData 博文修订记录响应结构
+type PostRevisionData struct {
+	Revision   uint64   `json:"revision"`   // 修订序号
+	EditorUID  uint64   `json:"editor_uid"` // 执行编辑的用户ID
+	Title      string   `json:"title"`      // 编辑前的标题
+	Content    string   `json:"content"`    // 编辑前的内容
+	Images     []string `json:"images"`     // 编辑前的图片文件名
+	ImageURLs  []string `json:"image_urls"` // 编辑前的图片 URL
+	Visibility string   `json:"visibility"` // 编辑前的可见性
+	EditedAt   int64    `json:"edited_at"`  // 编辑时间戳
+}
+
+// PostRevisionListResponse 博文修订记录列表响应结构
+type PostRevisionListResponse struct {
+	Revisions  []PostRevisionData `json:"revisions"`   // 修订记录列表
+	NextCursor *string            `json:"next_cursor"` // 下一页游标，没有下一页时为 null
+}
+
+// NewPostRevisionListResponse 创建新的博文修订记录列表响应
+//
+// 参数：
+//   - revisions：修订记录模型列表
+//   - nextCursor：下一页的游标
+//   - mediaStorage：媒体文件存储，用于生成图片 URL
+//
+// 返回值：
+//   - PostRevisionListResponse：新的博文修订记录列表响应结构
+func NewPostRevisionListResponse(revisions []models.PostRevision, nextCursor *types.Cursor, mediaStorage storages.MediaStorage) PostRevisionListResponse {
+	data := make([]PostRevisionData, 0, len(revisions))
+	for _, revision := range revisions {
+		images := []string(revision.Images)
+		if images == nil {
+			images = make([]string, 0)
+		}
+		data = append(data, PostRevisionData{
+			Revision:   revision.Revision,
+			EditorUID:  revision.EditorUID,
+			Title:      revision.Title,
+			Content:    revision.Content,
+			Images:     images,
+			ImageURLs:  newPostImageURLs(images, mediaStorage),
+			Visibility: revision.Visibility,
+			EditedAt:   revision.CreatedAt.Unix(),
+		})
+	}
+	return PostRevisionListResponse{Revisions: data, NextCursor: newCursorString(nextCursor)}
+}
+
+// TrashedPostData 回收站博文响应结构
+type TrashedPostData struct {
+	PostID        uint64   `json:"post_id"`        // 博文ID
+	Title         string   `json:"title"`          // 标题
+	Content       string   `json:"content"`        // 内容
+	Images        []string `json:"images"`         // 图片文件名
+	ImageURLs     []string `json:"image_urls"`     // 图片 URL
+	PostTimestamp int64    `json:"post_timestamp"` // 博文发布时间戳
+	DeletedAt     int64    `json:"deleted_at"`     // 删除时间戳
+}
+
+// TrashedPostListResponse 回收站博文列表响应结构
+type TrashedPostListResponse struct {
+	Posts      []TrashedPostData `json:"posts"`       // 博文列表
+	NextCursor *string           `json:"next_cursor"` // 下一页游标，没有下一页时为 null
+}
+
+// NewTrashedPostListResponse 创建新的回收站博文列表响应
+//
+// 参数：
+//   - posts：已删除的博文信息模型列表
+//   - nextCursor：下一页的游标
+//   - mediaStorage：媒体文件存储，用于生成图片 URL
+//
+// 返回值：
+//   - TrashedPostListResponse：新的回收站博文列表响应结构
+func NewTrashedPostListResponse(posts []models.PostInfo, nextCursor *types.Cursor, mediaStorage storages.MediaStorage) TrashedPostListResponse {
+	data := make([]TrashedPostData, 0, len(posts))
+	for _, post := range posts {
+		images := []string(post.Images)
+		if images == nil {
+			images = make([]string, 0)
+		}
+		data = append(data, TrashedPostData{
+			PostID:        uint64(post.ID),
+			Title:         post.Title,
+			Content:       post.Content,
+			Images:        images,
+			ImageURLs:     newPostImageURLs(images, mediaStorage),
+			PostTimestamp: post.CreatedAt.Unix(),
+			DeletedAt:     post.DeletedAt.Time.Unix(),
+		})
+	}
+	return TrashedPostListResponse{Posts: data, NextCursor: newCursorString(nextCursor)}
+}
+
+// newPostImageURLs 通过媒体文件存储生成博文图片的 URL
+//
+// 参数：
+//   - images：图片文件名
+//   - mediaStorage：媒体文件存储
+//
+// 返回值：
+//   - []string：与图片文件名一一对应的 URL
+func newPostImageURLs(images []string, mediaStorage storages.MediaStorage) []string {
+	urls := make([]string, 0, len(images))
+	for _, image := range images {
+		urls = append(urls, mediaStorage.URL(storages.PostImageKey(image)))
+	}
+	return urls
+}
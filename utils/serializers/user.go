@@ -7,9 +7,12 @@ Copyright (c) [2024], Author(s):
 package serializers
 
 import (
-	"strings"
+	"math"
+	"time"
 
 	"github.com/Kirisakiii/neko-micro-blog-backend/models"
+	"github.com/Kirisakiii/neko-micro-blog-backend/storages"
+	"github.com/Kirisakiii/neko-micro-blog-backend/types"
 )
 
 // UserProfileData 用户资料响应结构。
@@ -21,16 +24,27 @@ type UserProfileData struct {
 	Birth    *int64  `json:"birth"`      // 生日
 	Gender   *string `json:"gender"`     // 性别
 	Level    uint64  `json:"level"`      // 等级
+	IsSelf   bool    `json:"is_self"`    // 是否为访问者本人
+
+	FollowerCount  int64 `json:"follower_count"`  // 粉丝数
+	FollowingCount int64 `json:"following_count"` // 关注数
+	IsFollowing    bool  `json:"is_following"`    // 访问者是否关注了该用户
+
+	Email         *string `json:"email,omitempty"`          // 邮箱，仅本人可见
+	EmailVerified *bool   `json:"email_verified,omitempty"` // 邮箱是否已验证，仅本人可见
 }
 
 // NewUserProfileData 创建一个新的用户资料响应。
 //
 // 参数：
 //   - model：用户资料模型
+//   - stats：用户关系统计
+//   - viewerUID：访问者的用户ID，匿名访问时为 0
+//   - mediaStorage：媒体文件存储，用于生成头像 URL
 //
 // 返回值：
 //   - *UserProfileData：新的用户资料响应结构体。
-func NewUserProfileData(model *models.UserInfo) *UserProfileData {
+func NewUserProfileData(model *models.UserInfo, stats *types.UserRelationStats, viewerUID uint64, mediaStorage storages.MediaStorage) *UserProfileData {
 	// 创建用户资料响应
 	profile := new(UserProfileData)
 	profile.UID = uint64(model.ID)
@@ -45,10 +59,7 @@ func NewUserProfileData(model *models.UserInfo) *UserProfileData {
 	}
 
 	// 设置头像
-	var sb strings.Builder
-	sb.WriteString("/resources/avatar/")
-	sb.WriteString(model.Avatar)
-	profile.Avatar = sb.String()
+	profile.Avatar = mediaStorage.URL(storages.AvatarKey(model.Avatar))
 
 	// 设置生日和性别
 	// 如果生日和性别为空，则设置为未知
@@ -65,22 +76,225 @@ func NewUserProfileData(model *models.UserInfo) *UserProfileData {
 	}
 	profile.Level = model.Level
 
+	// 设置关系统计
+	profile.FollowerCount = stats.FollowerCount
+	profile.FollowingCount = stats.FollowingCount
+	profile.IsFollowing = stats.IsFollowing
+
+	// 设置访问者相关字段
+	profile.IsSelf = viewerUID != 0 && viewerUID == profile.UID
+	if profile.IsSelf {
+		profile.Email = model.Email
+		profile.EmailVerified = &model.EmailVerified
+	}
+
 	// 返回用户资料响应
 	return profile
 }
 
-// UserToken
+// UserListResponse 用户列表响应结构。
+type UserListResponse struct {
+	UIDs       []uint64 `json:"uids"`        // 用户ID列表
+	NextCursor *string  `json:"next_cursor"` // 下一页游标，没有下一页时为 null
+}
+
+// NewUserListResponse 创建一个新的用户列表响应。
+//
+// 参数：
+//   - uids：用户ID列表
+//   - nextCursor：下一页的游标
+//
+// 返回值：
+//   - UserListResponse：新的用户列表响应结构体。
+func NewUserListResponse(uids []uint64, nextCursor *types.Cursor) UserListResponse {
+	return UserListResponse{UIDs: uids, NextCursor: newCursorString(nextCursor)}
+}
+
+// UserToken 用户令牌响应结构。
 type UserToken struct {
-	Token string `json:"token"`
+	RequiresTwoFactor bool   `json:"requires_2fa"`        // 是否需要两步验证，恒为 false
+	Token             string `json:"token"`               // 访问令牌
+	ExpireTime        int64  `json:"expire_time"`         // 访问令牌过期时间戳
+	RefreshToken      string `json:"refresh_token"`       // 刷新令牌
+	RefreshExpireTime int64  `json:"refresh_expire_time"` // 刷新令牌过期时间戳
 }
 
 // NewUserToken 创建一个新的用户 Token 响应。
 //
 // 参数：
-//   - token：用户 Token
+//   - tokenPair：访问令牌与刷新令牌
 //
 // 返回值：
 //   - *UserToken：新的用户 Token 响应结构体。
-func NewUserToken(token string) *UserToken {
-	return &UserToken{Token: token}
+func NewUserToken(tokenPair *types.TokenPair) *UserToken {
+	return &UserToken{
+		Token:             tokenPair.AccessToken,
+		ExpireTime:        tokenPair.AccessExpireTime.Unix(),
+		RefreshToken:      tokenPair.RefreshToken,
+		RefreshExpireTime: tokenPair.RefreshExpireTime.Unix(),
+	}
+}
+
+// LoginChallengeData 需要两步验证时的登录响应结构。
+type LoginChallengeData struct {
+	RequiresTwoFactor   bool   `json:"requires_2fa"`          // 是否需要两步验证，恒为 true
+	ChallengeToken      string `json:"challenge_token"`       // 挑战令牌
+	ChallengeExpireTime int64  `json:"challenge_expire_time"` // 挑战令牌过期时间戳
+}
+
+// NewLoginChallengeData 创建一个新的两步验证挑战响应。
+//
+// 参数：
+//   - challenge：两步验证挑战
+//
+// 返回值：
+//   - *LoginChallengeData：新的两步验证挑战响应结构体。
+func NewLoginChallengeData(challenge *types.LoginChallenge) *LoginChallengeData {
+	return &LoginChallengeData{
+		RequiresTwoFactor:   true,
+		ChallengeToken:      challenge.ChallengeToken,
+		ChallengeExpireTime: challenge.ExpireTime.Unix(),
+	}
+}
+
+// TOTPEnrollmentData 两步验证注册响应结构。
+type TOTPEnrollmentData struct {
+	Secret          string `json:"secret"`           // base32 编码的密钥，供无法扫码时手动输入
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth URI，可生成二维码供身份验证器扫描
+}
+
+// NewTOTPEnrollmentData 创建一个新的两步验证注册响应。
+//
+// 参数：
+//   - enrollment：两步验证注册信息
+//
+// 返回值：
+//   - *TOTPEnrollmentData：新的两步验证注册响应结构体。
+func NewTOTPEnrollmentData(enrollment *types.TOTPEnrollment) *TOTPEnrollmentData {
+	return &TOTPEnrollmentData{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	}
+}
+
+// RecoveryCodesData 两步验证恢复码响应结构。
+type RecoveryCodesData struct {
+	RecoveryCodes []string `json:"recovery_codes"` // 恢复码列表，仅返回一次
+}
+
+// NewRecoveryCodesData 创建一个新的恢复码响应。
+//
+// 参数：
+//   - recoveryCodes：恢复码列表
+//
+// 返回值：
+//   - *RecoveryCodesData：新的恢复码响应结构体。
+func NewRecoveryCodesData(recoveryCodes []string) *RecoveryCodesData {
+	return &RecoveryCodesData{RecoveryCodes: recoveryCodes}
+}
+
+// LoginLockedData 登录被暂时锁定时的响应结构。
+type LoginLockedData struct {
+	RetryAfter int64 `json:"retry_after"` // 距离允许再次尝试的秒数
+}
+
+// NewLoginLockedData 创建一个新的登录锁定响应。
+//
+// 参数：
+//   - retryAfter：距离允许再次尝试的时长
+//
+// 返回值：
+//   - *LoginLockedData：新的登录锁定响应结构体。
+func NewLoginLockedData(retryAfter time.Duration) *LoginLockedData {
+	return &LoginLockedData{RetryAfter: int64(math.Ceil(retryAfter.Seconds()))}
+}
+
+// UserSessionData 用户会话响应结构。
+type UserSessionData struct {
+	SessionID   string `json:"session_id"`  // 会话ID
+	Device      string `json:"device"`      // 登录设备
+	Application string `json:"application"` // 登录应用
+	IP          string `json:"ip"`          // 登录IP
+	CreatedAt   int64  `json:"created_at"`  // 会话创建时间戳
+	ExpireTime  int64  `json:"expire_time"` // 会话过期时间戳
+	IsCurrent   bool   `json:"is_current"`  // 是否为当前会话
+}
+
+// UserSessionListResponse 用户会话列表响应结构。
+type UserSessionListResponse struct {
+	Sessions []UserSessionData `json:"sessions"` // 会话列表
+}
+
+// NewUserSessionListResponse 创建一个新的用户会话列表响应。
+//
+// 参数：
+//   - sessions：用户会话列表
+//   - currentSessionID：当前请求所属的会话ID
+//
+// 返回值：
+//   - UserSessionListResponse：新的用户会话列表响应结构体。
+func NewUserSessionListResponse(sessions []types.UserSession, currentSessionID string) UserSessionListResponse {
+	data := make([]UserSessionData, 0, len(sessions))
+	for _, session := range sessions {
+		// 缺少登录日志时设备与应用未知
+		device, application := session.Device, session.Application
+		if device == "" {
+			device = "unknown"
+		}
+		if application == "" {
+			application = "unknown"
+		}
+
+		data = append(data, UserSessionData{
+			SessionID:   session.SessionID,
+			Device:      device,
+			Application: application,
+			IP:          session.LoginIP,
+			CreatedAt:   session.CreatedAt.Unix(),
+			ExpireTime:  session.ExpireTime.Unix(),
+			IsCurrent:   session.SessionID == currentSessionID,
+		})
+	}
+	return UserSessionListResponse{Sessions: data}
+}
+
+// LoginEventData 登录事件响应结构。
+type LoginEventData struct {
+	ID          uint64 `json:"id"`            // 登录事件ID
+	LoginTime   int64  `json:"login_time"`    // 登录时间戳
+	IP          string `json:"ip"`            // 登录IP
+	Device      string `json:"device"`        // 登录设备
+	Application string `json:"application"`   // 登录应用
+	IsSucceed   bool   `json:"is_succeed"`    // 是否登录成功
+	Reason      string `json:"reason"`        // 失败原因
+	IsNewDevice bool   `json:"is_new_device"` // 是否为此前未成功登录过的设备
+}
+
+// LoginEventListResponse 登录事件列表响应结构。
+type LoginEventListResponse struct {
+	Events []LoginEventData `json:"events"` // 登录事件列表
+}
+
+// NewLoginEventListResponse 创建一个新的登录事件列表响应。
+//
+// 参数：
+//   - events：登录事件列表
+//
+// 返回值：
+//   - LoginEventListResponse：新的登录事件列表响应结构体。
+func NewLoginEventListResponse(events []types.LoginEvent) LoginEventListResponse {
+	data := make([]LoginEventData, 0, len(events))
+	for _, event := range events {
+		data = append(data, LoginEventData{
+			ID:          uint64(event.ID),
+			LoginTime:   event.LoginTime.Unix(),
+			IP:          event.LoginIP,
+			Device:      event.Device,
+			Application: event.Application,
+			IsSucceed:   event.IsSucceed,
+			Reason:      event.Reason,
+			IsNewDevice: event.IsNewDevice,
+		})
+	}
+	return LoginEventListResponse{Events: data}
 }
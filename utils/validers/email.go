@@ -0,0 +1,26 @@
+/*
+Package validers - NekoBlog backend server data validation.
+This file is for email validation.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package validers
+
+import "net/mail"
+
+// IsValidEmail 检查邮箱地址是否合法。
+//
+// 参数：
+//   - email：邮箱地址
+//
+// 返回值：
+//   - bool：如果邮箱地址合法，则返回true，否则返回false。
+func IsValidEmail(email string) bool {
+	if len(email) > 254 {
+		return false
+	}
+
+	// 仅接受不带显示名称的纯地址
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
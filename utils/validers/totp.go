@@ -0,0 +1,51 @@
+/*
+Package validers - NekoBlog backend server data validation.
+This file is for TOTP code validation.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package validers
+
+import (
+	"crypto/subtle"
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/encryptors"
+)
+
+// ValidateTOTPCode 校验 TOTP 验证码。
+//
+// 允许前后 TOTP_SKEW 个时间步的偏差，返回匹配的时间步序号以便调用方防止验证码重放。
+//
+// 参数：
+//   - secret：base32 编码的密钥
+//   - code：验证码
+//   - now：当前时间
+//
+// 返回值：
+//   - int64：匹配的时间步序号。
+//   - bool：验证码是否有效。
+func ValidateTOTPCode(secret string, code string, now time.Time) (int64, bool) {
+	if len(code) != consts.TOTP_DIGITS {
+		return 0, false
+	}
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	current := now.Unix() / consts.TOTP_PERIOD
+	for step := current - consts.TOTP_SKEW; step <= current+consts.TOTP_SKEW; step++ {
+		if step < 0 {
+			continue
+		}
+		expected := encryptors.ComputeHOTP(key, uint64(step), consts.TOTP_DIGITS)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,96 @@
+/*
+Package validers - NekoBlog backend server data validation.
+This file is for TOTP code validation tests.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package validers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kirisakiii/neko-micro-blog-backend/consts"
+	"github.com/Kirisakiii/neko-micro-blog-backend/utils/encryptors"
+)
+
+// rfcSecret RFC 6238 附录 B 中 SHA1 密钥 "12345678901234567890" 的 base32 编码
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestValidateTOTPCodeRFCVectors 使用 RFC 6238 附录 B 的 SHA1 测试向量（取低 6 位）校验验证码。
+func TestValidateTOTPCodeRFCVectors(t *testing.T) {
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, c := range cases {
+		step, ok := ValidateTOTPCode(rfcSecret, c.code, time.Unix(c.unix, 0))
+		if !ok {
+			t.Errorf("ValidateTOTPCode(time=%d, code=%s) rejected a valid code", c.unix, c.code)
+			continue
+		}
+		if step != c.unix/consts.TOTP_PERIOD {
+			t.Errorf("ValidateTOTPCode(time=%d) step = %d, want %d", c.unix, step, c.unix/consts.TOTP_PERIOD)
+		}
+	}
+}
+
+// TestValidateTOTPCodeSkew 校验仅接受前后 TOTP_SKEW 个时间步内的验证码。
+func TestValidateTOTPCodeSkew(t *testing.T) {
+	key := []byte("12345678901234567890")
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / consts.TOTP_PERIOD
+
+	cases := []struct {
+		name   string
+		offset int64
+		valid  bool
+	}{
+		{"current step", 0, true},
+		{"previous step", -consts.TOTP_SKEW, true},
+		{"next step", consts.TOTP_SKEW, true},
+		{"too old", -consts.TOTP_SKEW - 1, false},
+		{"too new", consts.TOTP_SKEW + 1, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			code := encryptors.ComputeHOTP(key, uint64(current+c.offset), consts.TOTP_DIGITS)
+			step, ok := ValidateTOTPCode(rfcSecret, code, now)
+			if ok != c.valid {
+				t.Fatalf("ValidateTOTPCode() valid = %v, want %v", ok, c.valid)
+			}
+			if ok && step != current+c.offset {
+				t.Fatalf("ValidateTOTPCode() step = %d, want %d", step, current+c.offset)
+			}
+		})
+	}
+}
+
+// TestValidateTOTPCodeMalformed 校验格式错误的验证码与密钥被拒绝。
+func TestValidateTOTPCodeMalformed(t *testing.T) {
+	now := time.Unix(59, 0)
+	cases := []struct {
+		name   string
+		secret string
+		code   string
+	}{
+		{"short code", rfcSecret, "28708"},
+		{"long code", rfcSecret, "94287082"},
+		{"wrong code", rfcSecret, "287083"},
+		{"invalid secret", "not base32!", "287082"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, ok := ValidateTOTPCode(c.secret, c.code, now); ok {
+				t.Fatalf("ValidateTOTPCode(%q, %q) accepted an invalid input", c.secret, c.code)
+			}
+		})
+	}
+}
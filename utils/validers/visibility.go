@@ -0,0 +1,25 @@
+/*
+Package validers - NekoBlog backend server data validation.
+This file is for content visibility validation.
+Copyright (c) [2024], Author(s):
+- WhitePaper233<baizhiwp@gmail.com>
+*/
+package validers
+
+import "github.com/Kirisakiii/neko-micro-blog-backend/consts"
+
+// IsValidVisibility 检查可见性设置是否合法。
+//
+// 参数：
+//   - visibility：可见性
+//
+// 返回值：
+//   - bool：如果可见性合法，则返回true，否则返回false。
+func IsValidVisibility(visibility string) bool {
+	switch visibility {
+	case consts.VISIBILITY_PUBLIC, consts.VISIBILITY_FOLLOWERS, consts.VISIBILITY_PRIVATE:
+		return true
+	default:
+		return false
+	}
+}